@@ -0,0 +1,45 @@
+package cli
+
+// A Binder stores the parsed value(s) of the input with the given id in c somewhere
+// else, typically in a field of a configuration struct. See [InputInfo.Bind].
+type Binder = func(c *Command, id string) error
+
+// BindTo returns a [Binder] that stores the parsed value of an input in dst, which means
+// the value no longer has to be retrieved by its ID after parsing. If there are multiple
+// values, the last one is used (just like [Lookup]). If there are none, dst is left
+// untouched, so whatever value it holds beforehand acts as a fallback. No reflection is
+// involved, so the parsed value must be of type T or binding will fail with a
+// [TypeMismatchError].
+func BindTo[T any](dst *T) Binder {
+	return func(c *Command, id string) error {
+		v, ok, err := TryLookup[T](c, id)
+		if ok && err == nil {
+			*dst = v
+		}
+		return err
+	}
+}
+
+// BindAll returns a [Binder] that stores every parsed value of an input in dst (just like
+// [GetAll]). If there are no values, dst is left untouched.
+func BindAll[T any](dst *[]T) Binder {
+	return func(c *Command, id string) error {
+		vals, err := TryGetAll[T](c, id)
+		if len(vals) > 0 {
+			*dst = vals
+		}
+		return err
+	}
+}
+
+// BindMap returns a [Binder] that stores every parsed key=value pair of a map option in
+// dst (just like [GetMap]). If there are no pairs, dst is left untouched.
+func BindMap[T any](dst *map[string]T) Binder {
+	return func(c *Command, id string) error {
+		m, err := TryGetMap[T](c, id)
+		if len(m) > 0 {
+			*dst = m
+		}
+		return err
+	}
+}
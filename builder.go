@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"encoding"
+	"io"
 	"runtime/debug"
 	"slices"
 	"strings"
@@ -16,6 +18,18 @@ var DefaultVersionOpt = NewVersionOpt('v', "version", VersionOptConfig{
 	HelpBlurb: "Print the build info version and exit",
 })
 
+// DefaultPrintConfigOpt is an option that, when provided, prints the resolved value and
+// source of every input on the parsed command path instead of returning the parsed
+// Command. It prints a table by default, or JSON if given the value "json" (as in
+// "--print-config=json"). Missing required inputs aren't reported as errors when it's
+// given, so they show up as not set instead, and a missing subcommand just ends the
+// printout at the last command that was given. Unlike the help option, this is never
+// added automatically.
+var DefaultPrintConfigOpt = NewBoolOpt("print-config").
+	Help("Print the resolved configuration and exit (use --print-config=json for JSON).").
+	WithParser(parseConfigFormat).
+	WithConfigPrinter(DefaultConfigPrinter)
+
 var (
 	errMixingPosArgsAndSubcmds = "commands cannot have both positional args and subcommands"
 	errEmptyCmdName            = "empty command name"
@@ -23,6 +37,7 @@ var (
 	errEmptyOptNames           = "options must have either a short or long name"
 	errOptAsPosArg             = "adding an option as a positional argument"
 	errReqArgAfterOptional     = "required positional arguments cannot come after optional ones"
+	errNilTypedParser          = "typed inputs need a non-nil parse function"
 )
 
 // New is intented to initialize a new root command. If no name is provided, it will use
@@ -59,47 +74,56 @@ func (c *CommandInfo) prepareAndValidate() {
 	}
 
 	// assert there are no duplicate input ids across the options and positional arguments
-	inputIDs := make([]string, 0, len(c.Opts)+len(c.Args))
-	for i := range len(c.Opts) {
-		id := c.Opts[i].ID
-		if slices.Contains(inputIDs, id) {
-			panic("command '" + strings.Join(c.Path, " ") +
-				"' contains duplicate input ids '" + id + "'")
-		}
-		inputIDs = append(inputIDs, id)
-	}
-	for i := range len(c.Args) {
-		id := c.Args[i].ID
-		if slices.Contains(inputIDs, id) {
-			panic("command '" + strings.Join(c.Path, " ") +
-				"' contains duplicate input ids '" + id + "'")
+	inputIDs := make(map[string]struct{}, len(c.Opts)+len(c.Args))
+	c.numPresets = 0
+	for _, infos := range [2][]InputInfo{c.Opts, c.Args} {
+		for i := range infos {
+			id := infos[i].ID
+			if _, ok := inputIDs[id]; ok {
+				panic("command '" + strings.Join(c.Path, " ") +
+					"' contains duplicate input ids '" + id + "'")
+			}
+			inputIDs[id] = struct{}{}
+
+			if infos[i].HasStrDefault {
+				c.numPresets++
+			}
+			if infos[i].EnvVar != "" {
+				c.numPresets++
+			}
 		}
-		inputIDs = append(inputIDs, id)
 	}
 
-	// option assertions
-	for i := 0; i < len(c.Opts)-1; i++ {
-		for z := i + 1; z < len(c.Opts); z++ {
-			// assert there are no duplicate long or short option names
-			if c.Opts[i].NameShort != 0 && c.Opts[i].NameShort == c.Opts[z].NameShort {
+	// Assert there are no duplicate long or short option names while indexing the options
+	// by those names for the parser.
+	c.optsByShort = make(map[byte]int, len(c.Opts))
+	c.optsByLong = make(map[string]int, len(c.Opts))
+	for i := range c.Opts {
+		if s := c.Opts[i].NameShort; s != 0 {
+			if _, ok := c.optsByShort[s]; ok {
 				panic("command '" + strings.Join(c.Path, " ") +
-					"' contains duplicate option short name '" + string(c.Opts[i].NameShort) + "'")
+					"' contains duplicate option short name '" + string(s) + "'")
 			}
-			if c.Opts[i].NameLong != "" && c.Opts[i].NameLong == c.Opts[z].NameLong {
+			c.optsByShort[s] = i
+		}
+		if l := c.Opts[i].NameLong; l != "" {
+			if _, ok := c.optsByLong[l]; ok {
 				panic("command '" + strings.Join(c.Path, " ") +
-					"' contains duplicate option long name '" + c.Opts[i].NameLong + "'")
+					"' contains duplicate option long name '" + l + "'")
 			}
+			c.optsByLong[l] = i
 		}
 	}
 
 	// subcommand names must be unique across Subcmds
-	for i := 0; i < len(c.Subcmds)-1; i++ {
-		for z := i + 1; z < len(c.Subcmds); z++ {
-			if c.Subcmds[i].Name == c.Subcmds[z].Name {
-				panic("command '" + strings.Join(c.Path, " ") +
-					"' contains duplicate subcommand name '" + c.Subcmds[i].Name + "'")
-			}
+	c.subcmdsByName = make(map[string]int, len(c.Subcmds))
+	for i := range c.Subcmds {
+		name := c.Subcmds[i].Name
+		if _, ok := c.subcmdsByName[name]; ok {
+			panic("command '" + strings.Join(c.Path, " ") +
+				"' contains duplicate subcommand name '" + name + "'")
 		}
+		c.subcmdsByName[name] = i
 	}
 	for i := range c.Subcmds {
 		c.Subcmds[i].Path = make([]string, len(c.Path)+1)
@@ -110,6 +134,113 @@ func (c *CommandInfo) prepareAndValidate() {
 	}
 }
 
+// Validate checks this command and all of its subcommands for every problem that would
+// otherwise cause a panic when building or parsing them, such as duplicate input IDs or
+// option names, and positional arguments mixed with subcommands. Unlike those panics,
+// which stop at the first problem, it returns all of the problems it finds as
+// [SchemaErrors] (or nil if there aren't any). The default help option that's added to
+// commands without one is taken into account. This is mainly useful for checking
+// schemas that are generated or built without the builder methods. Validate doesn't
+// modify this command.
+func (c CommandInfo) Validate() error {
+	var errs SchemaErrors
+	c.validate(&errs, []string{c.Name})
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validate adds any problems with this command (whose full path is given) and all of its
+// subcommands to errs.
+func (c *CommandInfo) validate(errs *SchemaErrors, path []string) {
+	add := func(problem string) {
+		*errs = append(*errs, SchemaError{Path: path, Problem: problem})
+	}
+	addInput := func(kind string, i int, in *InputInfo, problem string) {
+		*errs = append(*errs, SchemaError{
+			Path:       path,
+			InputKind:  kind,
+			InputIndex: i,
+			InputID:    in.ID,
+			Problem:    problem,
+		})
+	}
+
+	if c.Name == "" {
+		add(errEmptyCmdName)
+	} else if strings.ContainsFunc(c.Name, unicode.IsSpace) {
+		add("invalid command name '" + c.Name + "': cannot contain whitespace")
+	}
+	if len(c.Args) > 0 && len(c.Subcmds) > 0 {
+		add(errMixingPosArgsAndSubcmds)
+	}
+
+	// The default help option will be added when this command is prepared if it doesn't
+	// have one, so its names and ID need to be checked too.
+	opts := c.Opts
+	if !slices.ContainsFunc(opts, func(o InputInfo) bool { return o.HelpGen != nil }) {
+		opts = append(slices.Clip(opts), DefaultHelpInput)
+	}
+
+	inputIDs := make(map[string]struct{}, len(opts)+len(c.Args))
+	checkID := func(kind string, i int, in *InputInfo) {
+		if in.ID == "" {
+			addInput(kind, i, in, errEmptyInputID)
+			return
+		}
+		if _, ok := inputIDs[in.ID]; ok {
+			addInput(kind, i, in, "duplicate input id '"+in.ID+"'")
+		}
+		inputIDs[in.ID] = struct{}{}
+	}
+
+	shorts := make(map[byte]struct{}, len(opts))
+	longs := make(map[string]struct{}, len(opts))
+	for i := range opts {
+		o := &opts[i]
+		checkID(kindOption, i, o)
+		if !o.isOption() {
+			addInput(kindOption, i, o, errEmptyOptNames)
+		}
+		if s := o.NameShort; s != 0 {
+			if _, ok := shorts[s]; ok {
+				addInput(kindOption, i, o, "duplicate option short name '"+string(s)+"'")
+			}
+			shorts[s] = struct{}{}
+		}
+		if l := o.NameLong; l != "" {
+			if _, ok := longs[l]; ok {
+				addInput(kindOption, i, o, "duplicate option long name '"+l+"'")
+			}
+			longs[l] = struct{}{}
+		}
+	}
+
+	for i := range c.Args {
+		a := &c.Args[i]
+		checkID(kindArg, i, a)
+		if a.isOption() {
+			addInput(kindArg, i, a, errOptAsPosArg)
+		}
+		if a.IsRequired && i > 0 && !c.Args[i-1].IsRequired {
+			addInput(kindArg, i, a, errReqArgAfterOptional)
+		}
+	}
+
+	subcmdNames := make(map[string]struct{}, len(c.Subcmds))
+	for i := range c.Subcmds {
+		name := c.Subcmds[i].Name
+		if _, ok := subcmdNames[name]; ok && name != "" {
+			add("duplicate subcommand name '" + name + "'")
+		}
+		subcmdNames[name] = struct{}{}
+	}
+	for i := range c.Subcmds {
+		c.Subcmds[i].validate(errs, append(slices.Clip(path), c.Subcmds[i].Name))
+	}
+}
+
 // NewCmd returns a new CommandInfo with the Name field set to name and Path field set to
 // a single element slice of name. This function will panic if name is empty or contains
 // whitespace.
@@ -149,6 +280,27 @@ func (c CommandInfo) Usage(lines ...string) CommandInfo {
 	return c
 }
 
+// PromptMissing sets the Prompter of this CommandInfo to p. See the Prompter field
+// documentation on [CommandInfo] to learn more about how it is used.
+func (c CommandInfo) PromptMissing(p *Prompter) CommandInfo {
+	c.Prompter = p
+	return c
+}
+
+// WithStdin sets the Stdin of this CommandInfo to r. See the Stdin field documentation on
+// [CommandInfo] to learn more about how it is used.
+func (c CommandInfo) WithStdin(r io.Reader) CommandInfo {
+	c.Stdin = r
+	return c
+}
+
+// WithValidator sets the Validator of this CommandInfo to fn. See the Validator field
+// documentation on [CommandInfo] to learn more about how it is used.
+func (c CommandInfo) WithValidator(fn func(*Command) error) CommandInfo {
+	c.Validator = fn
+	return c
+}
+
 // SubcmdOptional sets the IsSubcmdOptional field of this CommandInfo to true.
 // See that field's documentation to learn more about how it is used.
 func (c CommandInfo) SubcmdOptional() CommandInfo {
@@ -165,6 +317,7 @@ func (c CommandInfo) Opt(o InputInfo) CommandInfo {
 		panic(errEmptyOptNames)
 	}
 	c.Opts = append(c.Opts, o)
+	c.isPrepped = false
 	return c
 }
 
@@ -187,6 +340,7 @@ func (c CommandInfo) Arg(pa InputInfo) CommandInfo {
 	}
 
 	c.Args = append(c.Args, pa)
+	c.isPrepped = false
 	return c
 }
 
@@ -198,6 +352,7 @@ func (c CommandInfo) Subcmd(sc CommandInfo) CommandInfo {
 		panic(errMixingPosArgsAndSubcmds)
 	}
 	c.Subcmds = append(c.Subcmds, sc)
+	c.isPrepped = false
 	return c
 }
 
@@ -236,6 +391,46 @@ func NewUintOpt(id string) InputInfo {
 	return NewOpt(id).WithParser(ParseUint)
 }
 
+// NewInt8Opt returns a new option that uses the [ParseInt8] value parser.
+func NewInt8Opt(id string) InputInfo {
+	return NewOpt(id).WithParser(ParseInt8)
+}
+
+// NewInt16Opt returns a new option that uses the [ParseInt16] value parser.
+func NewInt16Opt(id string) InputInfo {
+	return NewOpt(id).WithParser(ParseInt16)
+}
+
+// NewInt32Opt returns a new option that uses the [ParseInt32] value parser.
+func NewInt32Opt(id string) InputInfo {
+	return NewOpt(id).WithParser(ParseInt32)
+}
+
+// NewInt64Opt returns a new option that uses the [ParseInt64] value parser.
+func NewInt64Opt(id string) InputInfo {
+	return NewOpt(id).WithParser(ParseInt64)
+}
+
+// NewUint8Opt returns a new option that uses the [ParseUint8] value parser.
+func NewUint8Opt(id string) InputInfo {
+	return NewOpt(id).WithParser(ParseUint8)
+}
+
+// NewUint16Opt returns a new option that uses the [ParseUint16] value parser.
+func NewUint16Opt(id string) InputInfo {
+	return NewOpt(id).WithParser(ParseUint16)
+}
+
+// NewUint32Opt returns a new option that uses the [ParseUint32] value parser.
+func NewUint32Opt(id string) InputInfo {
+	return NewOpt(id).WithParser(ParseUint32)
+}
+
+// NewUint64Opt returns a new option that uses the [ParseUint64] value parser.
+func NewUint64Opt(id string) InputInfo {
+	return NewOpt(id).WithParser(ParseUint64)
+}
+
 // NewFloat32Opt returns a new option that uses the [ParseFloat32] value parser.
 func NewFloat32Opt(id string) InputInfo {
 	return NewOpt(id).WithParser(ParseFloat32)
@@ -246,6 +441,99 @@ func NewFloat64Opt(id string) InputInfo {
 	return NewOpt(id).WithParser(ParseFloat64)
 }
 
+// NewExtendedDurationOpt returns a new option that uses the [ParseExtendedDuration] value
+// parser and the [FormatExtendedDuration] value formatter.
+func NewExtendedDurationOpt(id string) InputInfo {
+	return NewOpt(id).WithParser(ParseExtendedDuration).WithFormatter(FormatExtendedDuration)
+}
+
+// NewByteSizeOpt returns a new option that uses the [ParseByteSize] value parser and
+// the [FormatByteSize] value formatter.
+func NewByteSizeOpt(id string) InputInfo {
+	return NewOpt(id).WithParser(ParseByteSize).WithFormatter(FormatByteSize)
+}
+
+// NewBigIntOpt returns a new option that uses the [ParseBigInt] value parser.
+func NewBigIntOpt(id string) InputInfo {
+	return NewOpt(id).WithParser(ParseBigInt)
+}
+
+// NewBigFloatOpt returns a new option that uses the [ParseBigFloat] value parser.
+func NewBigFloatOpt(id string) InputInfo {
+	return NewOpt(id).WithParser(ParseBigFloat)
+}
+
+// NewBigRatOpt returns a new option that uses the [ParseBigRat] value parser.
+func NewBigRatOpt(id string) InputInfo {
+	return NewOpt(id).WithParser(ParseBigRat)
+}
+
+// NewAddrOpt returns a new option that uses the [ParseAddr] value parser.
+func NewAddrOpt(id string) InputInfo {
+	return NewOpt(id).WithParser(ParseAddr)
+}
+
+// NewPrefixOpt returns a new option that uses the [ParsePrefix] value parser.
+func NewPrefixOpt(id string) InputInfo {
+	return NewOpt(id).WithParser(ParsePrefix)
+}
+
+// NewAddrPortOpt returns a new option that uses the [ParseAddrPort] value parser.
+func NewAddrPortOpt(id string) InputInfo {
+	return NewOpt(id).WithParser(ParseAddrPort)
+}
+
+// NewMACOpt returns a new option that uses the [ParseMAC] value parser.
+func NewMACOpt(id string) InputInfo {
+	return NewOpt(id).WithParser(ParseMAC)
+}
+
+// NewTextOpt returns a new option that uses the [ParseText] value parser for type T and
+// the [FormatText] value formatter. For example, "NewTextOpt[slog.Level]("level")" will
+// return an option that parses log levels and shows its default level as "WARN" (for
+// example) in help messages.
+func NewTextOpt[T any, PT interface {
+	*T
+	encoding.TextUnmarshaler
+}](id string) InputInfo {
+	return NewOpt(id).WithParser(ParseText[T, PT]).WithFormatter(FormatText)
+}
+
+// NewTextPtrOpt is like [NewTextOpt] except that it uses the [ParseTextPtr] value parser,
+// so its values are of type *T. For example, "NewTextPtrOpt[big.Int]("n")" will return an
+// option with *big.Int values.
+func NewTextPtrOpt[T any, PT interface {
+	*T
+	encoding.TextUnmarshaler
+}](id string) InputInfo {
+	return NewOpt(id).WithParser(ParseTextPtr[T, PT]).WithFormatter(FormatText)
+}
+
+// NewListOpt returns a new option that splits each of its raw values on commas and uses
+// the given [ValueParser] (if any) to parse each piece as its own value. This applies to
+// values from the command line, an env var, or a default value. The option can also be
+// provided multiple times, in which case all of the values are kept. Values from the
+// command line replace any from an env var or default value (see [ReplaceBySource]).
+// Use [InputInfo.WithSeparator] and [InputInfo.WithValuePolicy] to change either of
+// these behaviors, and use [GetAll] to retrieve the parsed values.
+func NewListOpt(id string, vp ValueParser) InputInfo {
+	return NewOpt(id).
+		WithParser(vp).
+		WithSeparator(",").
+		WithValuePolicy(ReplaceBySource)
+}
+
+// NewMapOpt returns a new option that takes key=value pairs (as in "--label k=v") and
+// uses the given [ValueParser] (if any) to parse the value of each pair. The option can
+// be provided multiple times, and use [InputInfo.WithSeparator] to allow multiple pairs
+// in a single value (as in "--label a=1,b=2"). Use [GetMap] to retrieve the parsed
+// pairs.
+func NewMapOpt(id string, vp ValueParser) InputInfo {
+	o := NewOpt(id).WithParser(vp).WithValueName("key=value")
+	o.IsMapOpt = true
+	return o
+}
+
 // NewArg returns a new positional argument input. By default, the arg's display name will
 // be the provided id, but this can be overidden with [InputInfo.WithValueName] method.
 func NewArg(id string) InputInfo {
@@ -263,6 +551,55 @@ func (in InputInfo) WithParser(vp ValueParser) InputInfo {
 	return in
 }
 
+// AllowStdin marks this input as one whose value can be read from stdin by giving it a
+// value of "-". See the ReadsStdin field documentation on [InputInfo] to learn more.
+func (in InputInfo) AllowStdin() InputInfo {
+	in.ReadsStdin = true
+	return in
+}
+
+// WithSeparator sets the separator used to split a single raw value into multiple
+// values. See the Separator field documentation on [InputInfo].
+func (in InputInfo) WithSeparator(sep string) InputInfo {
+	in.Separator = sep
+	return in
+}
+
+// WithValuePolicy sets how this input's values from different sources and repeated
+// occurrences are combined. See [ValuePolicy] for the available policies.
+func (in InputInfo) WithValuePolicy(vp ValuePolicy) InputInfo {
+	in.ValuePolicy = vp
+	return in
+}
+
+// UniqueKeys makes it a parsing error for this map option to be given the same key more
+// than once from the same source (for example, twice on the command line).
+func (in InputInfo) UniqueKeys() InputInfo {
+	in.HasUniqueKeys = true
+	return in
+}
+
+// WithValidators adds the given validators to this InputInfo. See the Validators field
+// documentation on [InputInfo] to learn more about how they are used.
+func (in InputInfo) WithValidators(vs ...Validator) InputInfo {
+	in.Validators = append(slices.Clip(in.Validators), vs...)
+	return in
+}
+
+// Bind sets the Binder of this InputInfo to b. See the Binder field documentation on
+// [InputInfo] to learn more about how it is used.
+func (in InputInfo) Bind(b Binder) InputInfo {
+	in.Binder = b
+	return in
+}
+
+// WithFormatter sets the ValueFormatter of this InputInfo. See the ValueFormatter field
+// documentation on [InputInfo] to learn more about how it is used.
+func (in InputInfo) WithFormatter(vf ValueFormatter) InputInfo {
+	in.ValueFormatter = vf
+	return in
+}
+
 // Short sets this option's short name to the given character. In order to create an
 // option that has a short name but no long name, see [InputInfo.ShortOnly].
 func (in InputInfo) Short(c byte) InputInfo {
@@ -308,6 +645,13 @@ func (in InputInfo) Required() InputInfo {
 	return in
 }
 
+// Secret marks this InputInfo as holding a sensitive value. See the IsSecret field
+// documentation on [InputInfo] to learn more about how it is used.
+func (in InputInfo) Secret() InputInfo {
+	in.IsSecret = true
+	return in
+}
+
 // WithValueName sets the display name of this InputInfo's argument value. For non-boolean
 // options, it's the argument of the option. For positional arguments, it's the argument
 // name itself.
@@ -343,6 +687,13 @@ func (in InputInfo) WithVersioner(ver Versioner) InputInfo {
 	return in
 }
 
+// WithConfigPrinter sets the ConfigPrinter field of this input. See the ConfigPrinter
+// field documentation on [InputInfo] to learn more about how it is used.
+func (in InputInfo) WithConfigPrinter(cp ConfigPrinter) InputInfo {
+	in.ConfigPrinter = cp
+	return in
+}
+
 // VersionOptConfig is used to pass customization values to [NewVersionOpt].
 type VersionOptConfig struct {
 	HelpBlurb        string
@@ -390,3 +741,19 @@ func NewVersionOpt(short byte, long string, cfg VersionOptConfig) InputInfo {
 func (in *InputInfo) isOption() bool {
 	return in.NameShort != 0 || in.NameLong != ""
 }
+
+// displayName returns the name a user would know this input by. For options, this is
+// the long name (or the short name if there is no long name) with its hyphen prefix. For
+// positional arguments, this is the value name.
+func (in *InputInfo) displayName() string {
+	switch {
+	case in.NameLong != "":
+		return "--" + in.NameLong
+	case in.NameShort != 0:
+		return "-" + string(in.NameShort)
+	case in.ValueName != "":
+		return in.ValueName
+	default:
+		return in.ID
+	}
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -223,3 +224,113 @@ func TestBuilder(t *testing.T) {
 		})
 	}
 }
+
+func TestValidate(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		cmd     CommandInfo
+		expErrs SchemaErrors
+	}{
+		{
+			name: "clean",
+			cmd: NewCmd("root").
+				Opt(NewOpt("aa").Short('a')).
+				Subcmd(NewCmd("sub").
+					Opt(NewBoolOpt("aa")).
+					Arg(NewArg("x").Required()).
+					Arg(NewArg("y"))),
+		}, {
+			name: "prepared",
+			cmd: func() CommandInfo {
+				in := NewCmd("root").Opt(NewOpt("aa")).Subcmd(NewCmd("sub"))
+				in.prepareAndValidate()
+				return in
+			}(),
+		}, {
+			name: "custom help option",
+			cmd: NewCmd("root").
+				Opt(NewBoolOpt("help").Short('h').WithHelpGen(DefaultHelpGenerator)),
+		}, {
+			name: "command problems",
+			cmd: CommandInfo{
+				Name: "root",
+				Args: []InputInfo{{ID: "a"}},
+				Subcmds: []CommandInfo{
+					{Name: ""},
+					{Name: "a b"},
+					{Name: "c"},
+					{Name: "c"},
+				},
+			},
+			expErrs: SchemaErrors{
+				{Path: []string{"root"}, Problem: errMixingPosArgsAndSubcmds},
+				{Path: []string{"root"}, Problem: "duplicate subcommand name 'c'"},
+				{Path: []string{"root", ""}, Problem: errEmptyCmdName},
+				{Path: []string{"root", "a b"}, Problem: "invalid command name 'a b': cannot contain whitespace"},
+			},
+		}, {
+			name: "input problems",
+			cmd: CommandInfo{
+				Name: "root",
+				Subcmds: []CommandInfo{{
+					Name: "sub",
+					Opts: []InputInfo{
+						{ID: "aa", NameLong: "aa"},
+						{ID: ""},
+						{ID: "bb"},
+						{ID: "aa", NameShort: 'a', NameLong: "aa"},
+						{ID: "cc", NameShort: 'a'},
+						{ID: "dd", NameShort: 'h'},
+					},
+					Args: []InputInfo{
+						{ID: "cc"},
+						{ID: "x", NameLong: "x", IsRequired: true},
+					},
+				}},
+			},
+			expErrs: SchemaErrors{
+				{Path: []string{"root", "sub"}, InputKind: "option", InputIndex: 1, Problem: errEmptyInputID},
+				{Path: []string{"root", "sub"}, InputKind: "option", InputIndex: 1, Problem: errEmptyOptNames},
+				{Path: []string{"root", "sub"}, InputKind: "option", InputIndex: 2, InputID: "bb", Problem: errEmptyOptNames},
+				{Path: []string{"root", "sub"}, InputKind: "option", InputIndex: 3, InputID: "aa", Problem: "duplicate input id 'aa'"},
+				{Path: []string{"root", "sub"}, InputKind: "option", InputIndex: 3, InputID: "aa", Problem: "duplicate option long name 'aa'"},
+				{Path: []string{"root", "sub"}, InputKind: "option", InputIndex: 4, InputID: "cc", Problem: "duplicate option short name 'a'"},
+				{Path: []string{"root", "sub"}, InputKind: "option", InputIndex: 6, InputID: "help", Problem: "duplicate option short name 'h'"},
+				{Path: []string{"root", "sub"}, InputKind: "positional argument", InputIndex: 0, InputID: "cc", Problem: "duplicate input id 'cc'"},
+				{Path: []string{"root", "sub"}, InputKind: "positional argument", InputIndex: 1, InputID: "x", Problem: errOptAsPosArg},
+				{Path: []string{"root", "sub"}, InputKind: "positional argument", InputIndex: 1, InputID: "x", Problem: errReqArgAfterOptional},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cmd.Validate()
+			if tt.expErrs == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !reflect.DeepEqual(err, tt.expErrs) {
+				t.Fatalf("schema errors don't match\nexpected: %v\n     got: %v", tt.expErrs, err)
+			}
+		})
+	}
+
+	// Each problem can be reached with errors.As.
+	err := CommandInfo{Name: "root", Opts: []InputInfo{{ID: "a"}}}.Validate()
+	var se SchemaError
+	if !errors.As(err, &se) || se.InputID != "a" || se.Problem != errEmptyOptNames {
+		t.Errorf("expected a schema error for input 'a', got %v", err)
+	}
+	const expMsg = "root: input 'a': options must have either a short or long name"
+	if err.Error() != expMsg {
+		t.Errorf("expected error message %q, got %q", expMsg, err.Error())
+	}
+
+	// Inputs without an ID are identified by their kind and index.
+	err = CommandInfo{Name: "root", Args: []InputInfo{{ID: "a"}, {}}}.Validate()
+	expEmptyMsg := "root: positional argument at index 1: " + errEmptyInputID
+	if err == nil || err.Error() != expEmptyMsg {
+		t.Errorf("expected error message %q, got %v", expEmptyMsg, err)
+	}
+}
@@ -6,7 +6,8 @@
 // (command line argument, environment variable, and default value), all will be parsed,
 // but the value from the command line will take precedence over the value from the
 // environment variable, and the value from the environment variable will take precedence
-// over the default value.
+// over the default value. Finally, if a command has a [Prompter] set, any required inputs
+// that are still missing after all of that can be prompted for interactively.
 //
 // # Command Line Syntax
 //
@@ -87,9 +88,11 @@ package cli
 import (
 	"errors"
 	"fmt"
+	"io"
 	"iter"
 	"os"
 	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -121,7 +124,33 @@ type CommandInfo struct {
 	// Command will be nil.
 	IsSubcmdOptional bool
 
+	// If Validator is set, it is called with the parsed Command for this CommandInfo
+	// once the entire command line has been parsed successfully. This is the place for
+	// rules that involve more than one input. Any error it returns is reported as a
+	// [CommandValidationError].
+	Validator func(*Command) error
+
+	// If Prompter is set, the parser will use it to prompt for the value of any required
+	// input that is missing after parsing this command. A Prompter set on a command is
+	// also used for all of its subcommands unless they set their own.
+	Prompter *Prompter
+
+	// Stdin is read by any input of this command that reads its value from stdin (see
+	// ReadsStdin on [InputInfo]). If this is nil, the Stdin of the nearest parent command
+	// that has one is used, or [os.Stdin] if none of them do.
+	Stdin io.Reader
+
 	isPrepped bool
+
+	// These index the options and subcommands of this command by name for the parser.
+	// They're built when this command is prepared for parsing.
+	optsByShort   map[byte]int
+	optsByLong    map[string]int
+	subcmdsByName map[string]int
+
+	// numPresets is the number of inputs that have a default value or an env var, which
+	// is used to size the parsed Inputs of this command.
+	numPresets int
 }
 
 type InputInfo struct {
@@ -133,12 +162,58 @@ type InputInfo struct {
 	IsBoolOpt  bool
 	IsRequired bool
 
+	// IsSecret marks this input's value as sensitive. A secret input's default value is
+	// never shown in help messages, its value is redacted in error messages and in the
+	// string form of its parsed [Input], and it's not echoed back when it's being
+	// prompted for (see [Prompter]).
+	IsSecret bool
+
+	// If ReadsStdin is true, a value of "-" for this input on the command line means that
+	// its actual raw value should be read from stdin (with a single trailing "\n" or
+	// "\r\n" removed). Only one input can read stdin in a single parse, and that includes an
+	// input whose value is an [InputFile] of "-" (see [ParseInputFile]). This allows values such
+	// as secrets to be piped in rather than appearing in the program's arguments.
+	ReadsStdin bool
+
 	StrDefault    string
 	HasStrDefault bool
 
 	ValueName   string
 	ValueParser ValueParser
 
+	// If ValueFormatter is set, it's used to turn a parsed value of this input back into
+	// a string wherever values are displayed. For example, a default value of "1048576"
+	// for a byte size input can be shown as "1MiB" in help messages.
+	ValueFormatter ValueFormatter
+
+	// Validators are run, in order, on every value of this input after it has been parsed
+	// by the ValueParser, no matter which source it came from. The first error returned
+	// by a validator is reported as an [InvalidValueError]. See [InRange] and the other
+	// provided validators for some examples.
+	Validators []Validator
+
+	// If Binder is set, it's called with the parsed [Command] and the ID of this input
+	// after a successful parse so that it can store this input's value(s) in a typed
+	// destination. Any error it returns is reported as a [BindError]. See [BindTo].
+	Binder Binder
+
+	// If Separator is set, every raw value for this input (whether it's from a command
+	// line argument, an env var, or a default value) is split on it, and each non-empty
+	// piece is parsed and added as its own value. See [NewListOpt].
+	Separator string
+
+	// ValuePolicy determines how values for this input from different sources and
+	// repeated occurrences are combined. See [ValuePolicy] for the options.
+	ValuePolicy ValuePolicy
+
+	// IsMapOpt marks this as an option that takes key=value pairs. Only the value of each
+	// pair is given to the ValueParser, and the parsed value of each pair is a [KeyValue].
+	// Later pairs override earlier pairs with the same key unless HasUniqueKeys is set,
+	// in which case the same key given twice from the same source is a parsing error.
+	// See [NewMapOpt].
+	IsMapOpt      bool
+	HasUniqueKeys bool
+
 	// If an input is encountered during parsing that has either HelpGen or Versioner
 	// set, the parser will return HelpOrVersionRequested with whatever either of those
 	// functions return as the Msg. See CommandInfo.ParseThese to learn more.
@@ -147,13 +222,55 @@ type InputInfo struct {
 	// library from automatically adding the DefaultHelpInput to that command.
 	HelpGen   HelpGenerator
 	Versioner Versioner
+
+	// If an input that has ConfigPrinter set is encountered during parsing, the parser
+	// will continue parsing the rest of the command line arguments as usual. If that all
+	// succeeds, it will then return HelpOrVersionRequested with whatever ConfigPrinter
+	// returns as the Msg instead of returning the parsed Command. See
+	// [DefaultPrintConfigOpt] for an example.
+	ConfigPrinter ConfigPrinter
 }
 
+// ValuePolicy describes how an input's values from different sources (default value, env
+// var, and command line) and from repeated occurrences on the command line are combined.
+type ValuePolicy uint8
+
+const (
+	// AppendValues keeps every value from every source in the order they were parsed.
+	// This is the default policy. [Lookup] and [Get] will return the last value, and
+	// [GetAll] will return all of them.
+	AppendValues ValuePolicy = iota
+
+	// ReplaceBySource drops any values that came from a source with a lower precedence
+	// when values from a higher precedence source are added. For example, values from
+	// the command line replace values from an env var or default value. Values from the
+	// same source (such as a repeated option) are still appended to one another.
+	ReplaceBySource
+
+	// OverrideValues drops all previous values whenever new values are added, so only
+	// the values from the last occurrence of the input remain. For example, a repeated
+	// option will only have the value it was given last.
+	OverrideValues
+
+	// RejectRepeats is like OverrideValues, except that providing an option more than
+	// once on the command line results in a [RepeatedOptionError].
+	RejectRepeats
+)
+
 // ValueParser describes any function that takes a string and returns some value or an
 // error. This is the signature of any input value parser. See [ParseBool], [ParseInt],
 // and the other provided parsers for some examples.
 type ValueParser = func(string) (any, error)
 
+// ValueFormatter describes any function that returns the display form of a parsed
+// value. See [FormatByteSize] for an example.
+type ValueFormatter = func(any) string
+
+// Validator describes any function that checks a parsed input value and returns an error
+// if the value is not acceptable. See [InRange], [OneOf], and the other provided
+// validators for some examples.
+type Validator = func(any) error
+
 // HelpGenerator describes any function that will return a help message based on the
 // [Input] that triggered it and the [CommandInfo] of which it is a member. See
 // [DefaultHelpGenerator] for an example.
@@ -163,21 +280,76 @@ type HelpGenerator = func(Input, *CommandInfo) string
 // the [Input] that triggered it. See [DefaultVersionOpt] for an example.
 type Versioner = func(Input) string
 
-// Command is a parsed command structure.
+// ConfigPrinter describes any function that will return a printout of the resolved
+// configuration based on the [Input] that triggered it, the root [CommandInfo], and the
+// successfully parsed root [Command]. See [DefaultConfigPrinter] for an example.
+type ConfigPrinter = func(src Input, in *CommandInfo, c *Command) string
+
+// Command is a parsed command structure. For commands with many parsed inputs, the parser
+// builds an index that speeds up looking values up by ID (as in [Lookup] and [GetAll]).
+// The index is ignored once Inputs is replaced or appended to, but changes made to the
+// existing elements of Inputs in place (such as changing an Input's ID) aren't detected,
+// so Inputs should be replaced with a modified copy instead.
 type Command struct {
 	Name    string
 	Inputs  []Input
 	Surplus []string
 	Subcmd  *Command
+	// Parent is the command that this is a subcommand of, or nil if this is the root. It's
+	// left out of JSON since it would make the structure cyclic.
+	Parent *Command `json:"-"`
+
+	// index is built by the parser for commands with many inputs. It's ignored if Inputs
+	// is replaced or appended to, so Inputs can still be changed that way, but doing so
+	// gives up the faster lookups.
+	index *inputIndex
+}
+
+// Root returns the root command of the parsed command chain that c belongs to.
+func (c *Command) Root() *Command {
+	for c.Parent != nil {
+		c = c.Parent
+	}
+	return c
+}
+
+// Chain returns an iterator over every command in the parsed command chain that c
+// belongs to, starting at the root command and ending at the last subcommand.
+func (c *Command) Chain() iter.Seq[*Command] {
+	return func(yield func(*Command) bool) {
+		for cmd := c.Root(); cmd != nil; cmd = cmd.Subcmd {
+			if !yield(cmd) {
+				return
+			}
+		}
+	}
 }
 
 // Input is a parsed option value or positional argument value along with other
 // information such as the input ID it corresponds to and where it was parsed from.
+//
+// Since Value is an interface, storing most parsed values in it takes an allocation.
+// Bools and ints from 0 to 255 are the exception, but other ints and strings are boxed,
+// which costs one allocation per value on top of the Command and its Inputs.
 type Input struct {
 	Value    any
 	ID       string
 	RawValue string
 	From     ParsedFrom
+	IsSecret bool
+}
+
+// redacted is what is shown in place of a secret input's value.
+const redacted = "[redacted]"
+
+// String returns the ID, value, and source of this Input in the form of "id=value
+// (source)". The value is redacted if this Input is secret, so unlike printing the
+// Value field directly, this is always safe to show or log.
+func (in Input) String() string {
+	if in.IsSecret {
+		return in.ID + "=" + redacted + " (" + in.From.String() + ")"
+	}
+	return fmt.Sprintf("%s=%v (%s)", in.ID, in.Value, in.From)
 }
 
 // ParsedFrom describes where an Input is parsed from. The place it came from will be the
@@ -187,6 +359,42 @@ type ParsedFrom struct {
 	Opt     string // Came from this provided option name.
 	Arg     int    // Appeared as the nth positional argument starting from 1.
 	Default bool   // Came from a provided default value.
+	Prompt  bool   // Was entered at a prompt for a missing required input.
+}
+
+// tier returns the precedence of this source. Values from a source with a higher tier
+// take precedence over values from a source with a lower one.
+func (pf ParsedFrom) tier() int {
+	switch {
+	case pf.Default:
+		return 0
+	case pf.Env != "":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// String returns a short description of this source, such as "option --name" or
+// "env var $NAME". It returns an empty string if this ParsedFrom is the zero value.
+func (pf ParsedFrom) String() string {
+	switch {
+	case pf.Opt != "":
+		if len(pf.Opt) == 1 {
+			return "option -" + pf.Opt
+		}
+		return "option --" + pf.Opt
+	case pf.Env != "":
+		return "env var $" + pf.Env
+	case pf.Arg != 0:
+		return "positional argument #" + strconv.Itoa(pf.Arg)
+	case pf.Default:
+		return "default value"
+	case pf.Prompt:
+		return "prompt"
+	default:
+		return ""
+	}
 }
 
 // Lookup looks for a parsed input value with the given id in the given Command and
@@ -195,10 +403,8 @@ type ParsedFrom struct {
 // is present, the typed value will be returned and the boolean will be true. Otherwise,
 // the zero value of type T will be returned and the boolean will be false.
 func Lookup[T any](c *Command, id string) (T, bool) {
-	for i := len(c.Inputs) - 1; i >= 0; i-- {
-		if c.Inputs[i].ID == id {
-			return c.Inputs[i].Value.(T), true
-		}
+	if i := c.lastPos(id); i != -1 {
+		return c.Inputs[i].Value.(T), true
 	}
 	var zero T
 	return zero, false
@@ -251,29 +457,197 @@ func GetOrFunc[T any](c *Command, id string, fn func() T) T {
 // found can't be converted to type T).
 func GetAll[T any](c *Command, id string) []T {
 	vals := make([]T, 0, len(c.Inputs)/3)
-	for i := range c.Inputs {
-		if c.Inputs[i].ID == id {
-			vals = append(vals, c.Inputs[i].Value.(T))
-		}
+	for i := c.firstPos(id); i != -1; i = c.nextPos(i) {
+		vals = append(vals, c.Inputs[i].Value.(T))
 	}
 	return vals
 }
 
+// KeyValue is the parsed value of a single key=value pair provided to a map option. The
+// Value is the result of the option's [ValueParser] on the value part of the pair.
+type KeyValue struct {
+	Key   string
+	Value any
+}
+
+// GetMap collects all parsed key=value pairs for the map option with the given id into
+// a map. Pairs are added in the order they were parsed, so a later pair overrides an
+// earlier one with the same key (e.g. a command line value overrides an env var value).
+// It converts each value to the given type T through an untested type assertion (so
+// this will panic if any value found can't be converted to type T). See [NewMapOpt].
+func GetMap[T any](c *Command, id string) map[string]T {
+	m := make(map[string]T)
+	for i := c.firstPos(id); i != -1; i = c.nextPos(i) {
+		kv := c.Inputs[i].Value.(KeyValue)
+		m[kv.Key] = kv.Value.(T)
+	}
+	return m
+}
+
 // GetAllSeq returns an iterator over each parsed value that has the given id. The
 // iterator yields the same values that would be returned by [GetAll](c, id) but without
 // constructing the slice.
 func GetAllSeq[T any](c *Command, id string) iter.Seq[T] {
 	return func(yield func(T) bool) {
-		for i := range c.Inputs {
-			if c.Inputs[i].ID == id {
-				if !yield(c.Inputs[i].Value.(T)) {
-					return
-				}
+		for i := c.firstPos(id); i != -1; i = c.nextPos(i) {
+			if !yield(c.Inputs[i].Value.(T)) {
+				return
 			}
 		}
 	}
 }
 
+// LookupInherited is like [Lookup] except that if the value isn't found in c, it looks in
+// each of c's parent commands in turn (nearest first). This allows a subcommand's handler
+// to read the values of options that belong to the commands above it, such as the root
+// command's global options, without needing a reference to those commands.
+//
+// The id is matched at every level, so the nearest command that has a value for it wins.
+// This means that an input of a subcommand shadows any input of a parent command that has
+// the same id. To read the value of a specific command's input instead, use [Lookup] on
+// that command (such as one found by walking up from c through Parent).
+func LookupInherited[T any](c *Command, id string) (T, bool) {
+	for ; c != nil; c = c.Parent {
+		if v, ok := Lookup[T](c, id); ok {
+			return v, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// GetInherited is like [Get] except that it looks in c's parent commands as well, as
+// described in [LookupInherited].
+func GetInherited[T any](c *Command, id string) T {
+	if v, ok := LookupInherited[T](c, id); ok {
+		return v
+	}
+	panic("no parsed input value for id '" + id + "'")
+}
+
+// GetOrInherited is like [GetOr] except that it looks in c's parent commands as well, as
+// described in [LookupInherited].
+func GetOrInherited[T any](c *Command, id string, fallback T) T {
+	if v, ok := LookupInherited[T](c, id); ok {
+		return v
+	}
+	return fallback
+}
+
+// GetAllInherited is like [GetAll] except that it looks in c's parent commands as well,
+// as described in [LookupInherited]. The values all come from the nearest command that
+// has any values for the given id, so values from different commands are never mixed.
+func GetAllInherited[T any](c *Command, id string) []T {
+	for ; c != nil; c = c.Parent {
+		if c.firstPos(id) != -1 {
+			return GetAll[T](c, id)
+		}
+	}
+	return []T{}
+}
+
+// TryLookupInherited is like [LookupInherited] except that it returns a
+// [TypeMismatchError] instead of panicking if the value is found but isn't of type T.
+func TryLookupInherited[T any](c *Command, id string) (T, bool, error) {
+	for ; c != nil; c = c.Parent {
+		if v, ok, err := TryLookup[T](c, id); ok {
+			return v, true, err
+		}
+	}
+	var zero T
+	return zero, false, nil
+}
+
+// TryLookup is like [Lookup] except that it returns a [TypeMismatchError] instead of
+// panicking if the value is found but isn't of type T.
+func TryLookup[T any](c *Command, id string) (T, bool, error) {
+	if i := c.lastPos(id); i != -1 {
+		v, err := typedValue[T](id, c.Inputs[i].Value)
+		return v, true, err
+	}
+	var zero T
+	return zero, false, nil
+}
+
+// TryGet is like [Get] except that it returns an error instead of panicking. The error
+// will be a [NotFoundError] if there is no value for the given id, or a
+// [TypeMismatchError] if the value isn't of type T.
+func TryGet[T any](c *Command, id string) (T, error) {
+	v, ok, err := TryLookup[T](c, id)
+	if !ok {
+		return v, NotFoundError{ID: id}
+	}
+	return v, err
+}
+
+// TryGetOr is like [GetOr] except that it returns a [TypeMismatchError] instead of
+// panicking if the value is found but isn't of type T.
+func TryGetOr[T any](c *Command, id string, fallback T) (T, error) {
+	v, ok, err := TryLookup[T](c, id)
+	if !ok {
+		return fallback, nil
+	}
+	return v, err
+}
+
+// TryGetAll is like [GetAll] except that it returns a [TypeMismatchError] instead of
+// panicking if any value found isn't of type T.
+func TryGetAll[T any](c *Command, id string) ([]T, error) {
+	vals := make([]T, 0, len(c.Inputs)/3)
+	for i := c.firstPos(id); i != -1; i = c.nextPos(i) {
+		v, err := typedValue[T](id, c.Inputs[i].Value)
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, v)
+	}
+	return vals, nil
+}
+
+// TryGetMap is like [GetMap] except that it returns a [TypeMismatchError] instead of
+// panicking if any value found isn't a [KeyValue] with a value of type T.
+func TryGetMap[T any](c *Command, id string) (map[string]T, error) {
+	m := make(map[string]T)
+	for i := c.firstPos(id); i != -1; i = c.nextPos(i) {
+		kv, err := typedValue[KeyValue](id, c.Inputs[i].Value)
+		if err != nil {
+			return nil, err
+		}
+		v, err := typedValue[T](id, kv.Value)
+		if err != nil {
+			return nil, err
+		}
+		m[kv.Key] = v
+	}
+	return m, nil
+}
+
+// typedValue converts v to type T, returning a [TypeMismatchError] if it can't.
+func typedValue[T any](id string, v any) (T, error) {
+	t, ok := v.(T)
+	if !ok {
+		return t, TypeMismatchError{
+			ID: id,
+			// Format a pointer so that interface types don't show up as "<nil>".
+			Expected: fmt.Sprintf("%T", (*T)(nil))[1:],
+			Actual:   fmt.Sprintf("%T", v),
+		}
+	}
+	return t, nil
+}
+
+// walkParsed calls fn with each level of the parsed command path (from the root down to
+// the last subcommand) along with the CommandInfo that level was parsed against.
+func walkParsed(in *CommandInfo, c *Command, fn func(*CommandInfo, *Command)) {
+	for in != nil && c != nil {
+		fn(in, c)
+		if c.Subcmd == nil {
+			return
+		}
+		in, c = lookupSubcmd(in, c.Subcmd.Name), c.Subcmd
+	}
+}
+
 // Fatal logs the given value to Stderr prefixed by "error: "
 // and then exits the program with the given code.
 func Fatal(code int, v any) {
@@ -292,7 +666,15 @@ func (in CommandInfo) ParseOrExit() *Command {
 // exit with status code 0. If there was any other error, it will print the error's
 // message to Stderr and exit with status code 1.
 func (in CommandInfo) ParseTheseOrExit(args ...string) *Command {
-	c, err := in.ParseThese(args...)
+	// This is parsed against a Schema so that nothing shared with the caller's
+	// CommandInfo is modified while it's being prepared.
+	return in.Prepare().ParseTheseOrExit(args...)
+}
+
+// exitOnError returns c if err is nil. Otherwise, if err is a [HelpOrVersionRequested]
+// error, it prints the message and exits with status code 0, and for any other error, it
+// prints the error's message to Stderr and exits with status code 1.
+func exitOnError(c *Command, err error) *Command {
 	if err != nil {
 		if e, ok := err.(HelpOrVersionRequested); ok {
 			fmt.Print(e.Msg)
@@ -314,23 +696,173 @@ func (in *CommandInfo) Parse() (*Command, error) {
 // involves setting the Path field of this command and all subcommands, as well as
 // ensuring there are no logical errors in the structure of this command (such as a
 // command containing duplicate option names). This method will panic if there are any
-// schema errors in this CommandInfo.
+// schema errors in this CommandInfo. Use [CommandInfo.Validate] to find them all without
+// panicking.
 //
 // Assuming a clean schema, this method then parses input against this CommandInfo using
 // args as the command line arguments. If there is a help or version input found on any
 // command level, this function will return a [HelpOrVersionRequested] error.
+//
+// Since this method prepares the CommandInfo the first time it's called, it isn't safe
+// to call concurrently. Use [CommandInfo.Prepare] to parse against the same command
+// from multiple goroutines.
 func (in *CommandInfo) ParseThese(args ...string) (*Command, error) {
 	if !in.isPrepped {
 		in.prepareAndValidate()
 		in.isPrepped = true
 	}
+	return in.parsePrepared(args)
+}
+
+// parsePrepared parses args against this CommandInfo, which must already be prepared.
+// It doesn't modify the CommandInfo, so it's safe to call concurrently.
+func (in *CommandInfo) parsePrepared(args []string) (*Command, error) {
 	c := &Command{
-		Inputs: make([]Input, 0, len(args)),
+		Inputs: make([]Input, 0, len(args)+in.numPresets),
+	}
+	var ps parseState
+	err := parse(in, c, args, &ps)
+	if err == nil {
+		err = claimStdinFiles(in, c, &ps)
 	}
-	err := parse(in, c, args)
+	if err != nil {
+		return c, err
+	}
+	if ps.configOpt != nil {
+		return c, HelpOrVersionRequested{
+			Msg: ps.configOpt.ConfigPrinter(ps.configInput, in, c),
+		}
+	}
+	walkParsed(in, c, func(in *CommandInfo, c *Command) {
+		if err == nil && in.Validator != nil {
+			if vErr := in.Validator(c); vErr != nil {
+				err = CommandValidationError{CmdInfo: in, Err: vErr}
+			}
+		}
+	})
+	if err != nil {
+		return c, err
+	}
+	walkParsed(in, c, func(in *CommandInfo, c *Command) {
+		for _, infos := range [2][]InputInfo{in.Opts, in.Args} {
+			for i := range infos {
+				if err == nil && infos[i].Binder != nil {
+					if bErr := infos[i].Binder(c, infos[i].ID); bErr != nil {
+						err = BindError{CmdInfo: in, InputInfo: &infos[i], Err: bErr}
+					}
+				}
+			}
+		}
+	})
 	return c, err
 }
 
+// parseState holds anything that needs to be tracked across every command level
+// while parsing a single set of command line arguments.
+type parseState struct {
+	// prompter is the Prompter of the deepest command parsed so far that has one.
+	prompter *Prompter
+	// configOpt is the input that requested a configuration printout (if any), and
+	// configInput is the parsed input for it.
+	configOpt   *InputInfo
+	configInput Input
+	// stdin is the Stdin of the deepest command parsed so far that has one, and stdinUser
+	// is the input that has already read it (if any).
+	stdin     io.Reader
+	stdinUser *InputInfo
+}
+
+// activePrompter returns the Prompter that should be used to prompt for missing
+// values, or nil if nothing should be prompted for.
+func (ps *parseState) activePrompter() *Prompter {
+	// There's no need to prompt for anything if we're only going to print the resolved
+	// configuration.
+	if ps.configOpt != nil {
+		return nil
+	}
+	return ps.prompter
+}
+
+// stdinValue returns the raw value for an input given on the command line. If the input
+// reads stdin and its value is "-", the content of stdin is returned instead.
+func (ps *parseState) stdinValue(c *CommandInfo, info *InputInfo, src ParsedFrom, rawValue string) (string, error) {
+	if !info.ReadsStdin || rawValue != "-" {
+		return rawValue, nil
+	}
+	r, err := ps.claimStdin(info)
+	if err != nil {
+		return "", newInvalidValueError(c, info, src, rawValue, err)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return "", newInvalidValueError(c, info, src, rawValue, fmt.Errorf("reading stdin: %w", err))
+	}
+	// Drop a single trailing line ending of either kind.
+	s := string(b)
+	if t, ok := strings.CutSuffix(s, "\n"); ok {
+		s = strings.TrimSuffix(t, "\r")
+	}
+	return s, nil
+}
+
+// claimStdin marks stdin as used by the given input and returns the reader for it. Only
+// one input can use stdin in a single parse, so an error is returned if another input
+// already has.
+func (ps *parseState) claimStdin(info *InputInfo) (io.Reader, error) {
+	if ps.stdinUser != nil {
+		return nil, fmt.Errorf("stdin was already read for %s", ps.stdinUser.displayName())
+	}
+	ps.stdinUser = info
+	if ps.stdin == nil {
+		return os.Stdin, nil
+	}
+	return ps.stdin, nil
+}
+
+// claimStdinFiles gives each parsed [InputFile] of "-" the stdin reader of its command,
+// which counts as reading stdin (see ReadsStdin on [InputInfo]). This is only done once
+// parsing is done, and only for values from the same source as the last value of their
+// input, so that values that end up being replaced or overridden (such as a default
+// value of "-" when a file is given on the command line) don't keep anything else from
+// reading stdin.
+func claimStdinFiles(in *CommandInfo, c *Command, ps *parseState) error {
+	var err error
+	ps.stdin = nil
+	walkParsed(in, c, func(in *CommandInfo, c *Command) {
+		if in.Stdin != nil {
+			ps.stdin = in.Stdin
+		}
+		for i := range c.Inputs {
+			pi := &c.Inputs[i]
+			f, ok := pi.Value.(InputFile)
+			if err != nil || !ok || f.Path != "-" ||
+				c.Inputs[c.lastPos(pi.ID)].From.tier() != pi.From.tier() {
+				continue
+			}
+			info := lookupInputByID(in, pi.ID)
+			if f.stdin, err = ps.claimStdin(info); err != nil {
+				err = newInvalidValueError(in, info, pi.From, pi.RawValue, err)
+				return
+			}
+			pi.Value = f
+		}
+	})
+	return err
+}
+
+// lookupInputByID returns the option or positional argument of in with the given ID, or
+// nil if there isn't one.
+func lookupInputByID(in *CommandInfo, id string) *InputInfo {
+	for _, infos := range [2][]InputInfo{in.Opts, in.Args} {
+		for i := range infos {
+			if infos[i].ID == id {
+				return &infos[i]
+			}
+		}
+	}
+	return nil
+}
+
 // HelpOrVersionRequested is returned by the parsing code
 // to signal that a help or version option was encountered.
 type HelpOrVersionRequested struct {
@@ -342,52 +874,57 @@ func (h HelpOrVersionRequested) Error() string {
 }
 
 func lookupOptionByShortName(in *CommandInfo, shortName byte) *InputInfo {
-	for i := range in.Opts {
-		if in.Opts[i].NameShort == shortName {
-			return &in.Opts[i]
-		}
+	if i, ok := in.optsByShort[shortName]; ok {
+		return &in.Opts[i]
 	}
 	return nil
 }
 
-func hasOpt(c *Command, id string) bool {
-	for i := range c.Inputs {
-		if c.Inputs[i].ID == id {
-			return true
-		}
+func lookupOptionByLongName(in *CommandInfo, longName string) *InputInfo {
+	if i, ok := in.optsByLong[longName]; ok {
+		return &in.Opts[i]
 	}
-	return false
+	return nil
 }
 
-func hasArg(c *Command, id string) bool {
-	for i := range c.Inputs {
-		if c.Inputs[i].ID == id {
-			return true
-		}
+func lookupSubcmd(in *CommandInfo, name string) *CommandInfo {
+	if i, ok := in.subcmdsByName[name]; ok {
+		return &in.Subcmds[i]
 	}
-	return false
+	return nil
+}
+
+func hasOpt(c *Command, id string) bool {
+	return c.lastPos(id) != -1
 }
 
-func parse(c *CommandInfo, p *Command, args []string) error {
+func hasArg(c *Command, id string) bool {
+	return c.lastPos(id) != -1
+}
+
+func parse(c *CommandInfo, p *Command, args []string, ps *parseState) error {
+	if c.Prompter != nil {
+		ps.prompter = c.Prompter
+	}
+	if c.Stdin != nil {
+		ps.stdin = c.Stdin
+	}
+
 	// set any defaults
 	for i := range c.Opts {
 		if c.Opts[i].HasStrDefault {
-			dv := c.Opts[i].StrDefault
-			pi, err := newInput(&c.Opts[i], ParsedFrom{Default: true}, dv)
+			err := addInputs(c, p, &c.Opts[i], ParsedFrom{Default: true}, c.Opts[i].StrDefault)
 			if err != nil {
-				return fmt.Errorf("parsing default value '%s' for option '%s': %w", dv, c.Opts[i].ID, err)
+				return err
 			}
-			p.Inputs = append(p.Inputs, pi)
 		}
 	}
 	for i := range c.Args {
 		if c.Args[i].HasStrDefault {
-			dv := c.Args[i].StrDefault
-			pi, err := newInput(&c.Args[i], ParsedFrom{Default: true}, dv)
+			err := addInputs(c, p, &c.Args[i], ParsedFrom{Default: true}, c.Args[i].StrDefault)
 			if err != nil {
-				return fmt.Errorf("parsing default value '%s' for arg '%s': %w", dv, c.Args[i].ID, err)
+				return err
 			}
-			p.Inputs = append(p.Inputs, pi)
 		}
 	}
 
@@ -395,22 +932,18 @@ func parse(c *CommandInfo, p *Command, args []string) error {
 	for i := range c.Opts {
 		if c.Opts[i].EnvVar != "" {
 			if v, ok := os.LookupEnv(c.Opts[i].EnvVar); ok {
-				pi, err := newInput(&c.Opts[i], ParsedFrom{Env: c.Opts[i].EnvVar}, v)
-				if err != nil {
-					return fmt.Errorf("using env var '%s': %w", c.Opts[i].EnvVar, err)
+				if err := addInputs(c, p, &c.Opts[i], ParsedFrom{Env: c.Opts[i].EnvVar}, v); err != nil {
+					return err
 				}
-				p.Inputs = append(p.Inputs, pi)
 			}
 		}
 	}
 	for i := range c.Args {
 		if c.Args[i].EnvVar != "" {
 			if v, ok := os.LookupEnv(c.Args[i].EnvVar); ok {
-				pi, err := newInput(&c.Args[i], ParsedFrom{Env: c.Args[i].EnvVar}, v)
-				if err != nil {
-					return fmt.Errorf("using env var '%s': %w", c.Args[i].EnvVar, err)
+				if err := addInputs(c, p, &c.Args[i], ParsedFrom{Env: c.Args[i].EnvVar}, v); err != nil {
+					return err
 				}
-				p.Inputs = append(p.Inputs, pi)
 			}
 		}
 	}
@@ -467,24 +1000,11 @@ func parse(c *CommandInfo, p *Command, args []string) error {
 					}
 				}
 
-				pi, err := newInput(optInfo, ParsedFrom{Opt: string(optName)}, rawValue)
+				err := addOptInputs(c, p, ps, optInfo, ParsedFrom{Opt: arg[charIdx : charIdx+1]}, rawValue)
 				if err != nil {
-					return fmt.Errorf("parsing option '%c': %w", optName, err)
+					return err
 				}
 
-				if optInfo.HelpGen != nil {
-					return HelpOrVersionRequested{
-						Msg: optInfo.HelpGen(pi, c),
-					}
-				}
-				if optInfo.Versioner != nil {
-					return HelpOrVersionRequested{
-						Msg: optInfo.Versioner(pi),
-					}
-				}
-
-				p.Inputs = append(p.Inputs, pi)
-
 				if skipRest {
 					break
 				}
@@ -517,12 +1037,7 @@ func parse(c *CommandInfo, p *Command, args []string) error {
 		if len(name) == 1 {
 			optInfo = lookupOptionByShortName(c, name[0])
 		} else {
-			for i := range c.Opts {
-				if name == c.Opts[i].NameLong {
-					optInfo = &c.Opts[i]
-					break
-				}
-			}
+			optInfo = lookupOptionByLongName(c, name)
 		}
 		if optInfo == nil {
 			return UnknownOptionError{CmdInfo: c, Name: args[i]}
@@ -540,73 +1055,58 @@ func parse(c *CommandInfo, p *Command, args []string) error {
 			}
 		}
 
-		pi, err := newInput(optInfo, ParsedFrom{Opt: name}, rawValue)
-		if err != nil {
-			return fmt.Errorf("parsing option '%s': %w", name, err)
-		}
-
-		if optInfo.HelpGen != nil {
-			return HelpOrVersionRequested{
-				Msg: optInfo.HelpGen(pi, c),
-			}
+		if err := addOptInputs(c, p, ps, optInfo, ParsedFrom{Opt: name}, rawValue); err != nil {
+			return err
 		}
-		if optInfo.Versioner != nil {
-			return HelpOrVersionRequested{
-				Msg: optInfo.Versioner(pi),
-			}
-		}
-
-		p.Inputs = append(p.Inputs, pi)
 	}
 
-	// check that all required options were provided
-	var missing []string
-	for i := range c.Opts {
-		if c.Opts[i].IsRequired {
-			if !hasOpt(p, c.Opts[i].ID) {
-				var name string
-				if c.Opts[i].NameLong != "" {
-					name = "--" + c.Opts[i].NameLong
-				} else {
-					name = "-" + string(c.Opts[i].NameShort)
-				}
-				missing = append(missing, name)
-			}
-		}
-	}
+	// Check that all required options were provided. If we are about to parse positional
+	// arguments instead of subcommands, we can do this right now. Otherwise we have to
+	// wait to see if a subcommand requests help before prompting or returning an error.
 	var errMissingOpts error
-	if len(missing) > 0 {
-		errMissingOpts = MissingOptionsError{CmdInfo: c, Names: missing}
-
-		// If we are about to parse positional arguments instead of subcommands,
-		// we can just return this error right now. Otherwise we have to wait
-		// to see if a subcommand requests help.
-		if len(c.Subcmds) == 0 {
-			return errMissingOpts
+	if len(c.Subcmds) == 0 {
+		if err := checkRequiredOpts(c, p, ps, ps.activePrompter()); err != nil {
+			return err
 		}
+	} else {
+		errMissingOpts = checkRequiredOpts(c, p, ps, nil)
 	}
 
 	rest := args[i:]
 	if len(c.Subcmds) == 0 {
 		for i = 0; i < len(c.Args); i++ {
 			if i < len(rest) {
-				rawArg := rest[i]
-				pi, err := newInput(&c.Args[i], ParsedFrom{Arg: i + 1}, rawArg)
+				src := ParsedFrom{Arg: i + 1}
+				rawValue, err := ps.stdinValue(c, &c.Args[i], src, rest[i])
 				if err != nil {
-					return fmt.Errorf("parsing positional argument #%d '%s': %w", i+1, rawArg, err)
+					return err
+				}
+				if err := addInputs(c, p, &c.Args[i], src, rawValue); err != nil {
+					return err
+				}
+				// A raw value that splits into nothing doesn't provide a required argument.
+				if c.Args[i].IsRequired && !hasArg(p, c.Args[i].ID) && ps.configOpt == nil {
+					return MissingArgsError{CmdInfo: c, Names: []string{c.Args[i].ValueName}}
 				}
-				p.Inputs = append(p.Inputs, pi)
 			} else if c.Args[i].IsRequired {
 				var missing []string
 				for ; i < len(c.Args); i++ {
 					if !c.Args[i].IsRequired {
 						break
 					}
-					if !hasArg(p, c.Args[i].ID) {
+					if hasArg(p, c.Args[i].ID) {
+						continue
+					}
+					ok, err := promptFor(c, p, &c.Args[i], ps.activePrompter())
+					if err != nil {
+						return err
+					}
+					if !ok {
 						missing = append(missing, c.Args[i].ValueName)
 					}
 				}
-				if len(missing) > 0 {
+				// Missing values are still printed when the configuration is requested.
+				if len(missing) > 0 && ps.configOpt == nil {
 					return MissingArgsError{CmdInfo: c, Names: missing}
 				}
 				return nil
@@ -621,47 +1121,195 @@ func parse(c *CommandInfo, p *Command, args []string) error {
 	}
 
 	if len(rest) < 1 {
-		if c.IsSubcmdOptional {
+		// The configuration is still printed for the commands that were matched when the
+		// subcommand is missing, just like it is when inputs are missing.
+		if c.IsSubcmdOptional || ps.configOpt != nil {
 			return nil
 		}
 		return ErrNoSubcmd
 	}
 
-	var subcmdInfo *CommandInfo
-	for i := range c.Subcmds {
-		if c.Subcmds[i].Name == rest[0] {
-			subcmdInfo = &c.Subcmds[i]
-			break
-		}
-	}
+	subcmdInfo := lookupSubcmd(c, rest[0])
 	if subcmdInfo == nil {
 		return UnknownSubcmdError{CmdInfo: c, Name: rest[0]}
 	}
 	p.Subcmd = &Command{
-		Inputs: make([]Input, 0, len(rest)),
+		Inputs: make([]Input, 0, len(rest)+subcmdInfo.numPresets),
 		Name:   rest[0],
+		Parent: p,
 	}
 
-	// If we have an error from parsing this command (from above), only return it so long
-	// as no subcommand has requested a help message.
-	errFromSubcmd := parse(subcmdInfo, p.Subcmd, rest[1:])
+	// If we have missing options on this command (from above), only report them so long
+	// as no subcommand has requested a help message. We only prompt for them if the
+	// subcommand was parsed successfully.
+	errFromSubcmd := parse(subcmdInfo, p.Subcmd, rest[1:], ps)
 	if errMissingOpts != nil {
-		if _, ok := errFromSubcmd.(HelpOrVersionRequested); !ok {
+		if _, ok := errFromSubcmd.(HelpOrVersionRequested); ok {
+			return errFromSubcmd
+		}
+		if errFromSubcmd != nil {
 			return errMissingOpts
 		}
+		if err := checkRequiredOpts(c, p, ps, ps.activePrompter()); err != nil {
+			return err
+		}
 	}
 
 	return errFromSubcmd
 }
 
+// checkRequiredOpts returns a [MissingOptionsError] if any of the required options of c
+// don't have a value in p. If pr is not nil, it is first used to prompt for the value of
+// each missing option. Missing options aren't an error if the configuration is going to
+// be printed, since that's exactly when seeing what's missing is useful.
+func checkRequiredOpts(c *CommandInfo, p *Command, ps *parseState, pr *Prompter) error {
+	var missing []string
+	for i := range c.Opts {
+		if !c.Opts[i].IsRequired || hasOpt(p, c.Opts[i].ID) {
+			continue
+		}
+		ok, err := promptFor(c, p, &c.Opts[i], pr)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			missing = append(missing, c.Opts[i].displayName())
+		}
+	}
+	if len(missing) > 0 && ps.configOpt == nil {
+		return MissingOptionsError{CmdInfo: c, Names: missing}
+	}
+	return nil
+}
+
+// promptFor uses pr (if it isn't nil) to prompt for a value for the given input. If a
+// value is entered, it's parsed and added to p. It reports whether a value was added.
+func promptFor(c *CommandInfo, p *Command, info *InputInfo, pr *Prompter) (bool, error) {
+	if pr == nil {
+		return false, nil
+	}
+	rawValue, ok, err := pr.prompt(info)
+	if err != nil || !ok {
+		return false, err
+	}
+	if err := addInputs(c, p, info, ParsedFrom{Prompt: true}, rawValue); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// addOptInputs handles a single occurrence of an option. Help, version, and config
+// printing options are handled here, and any other option is passed on to [addInputs].
+func addOptInputs(c *CommandInfo, p *Command, ps *parseState, info *InputInfo, src ParsedFrom, rawValue string) error {
+	if info.HelpGen == nil && info.Versioner == nil && info.ConfigPrinter == nil {
+		rawValue, err := ps.stdinValue(c, info, src, rawValue)
+		if err != nil {
+			return err
+		}
+		return addInputs(c, p, info, src, rawValue)
+	}
+
+	pi, err := newInput(info, src, rawValue)
+	if err != nil {
+		return newInvalidValueError(c, info, src, rawValue, err)
+	}
+	switch {
+	case info.HelpGen != nil:
+		return HelpOrVersionRequested{Msg: info.HelpGen(pi, c)}
+	case info.Versioner != nil:
+		return HelpOrVersionRequested{Msg: info.Versioner(pi)}
+	default:
+		ps.configOpt, ps.configInput = info, pi
+		return nil
+	}
+}
+
+// addInputs parses the given raw value for an input and adds the result to p while
+// following the input's ValuePolicy. Inputs that have a Separator will have their raw
+// value split into separate values, each of which is parsed and added on its own. Any
+// values that the new ones replace are only removed once at least one new value has
+// been added, so a raw value that splits into nothing (such as ",") replaces nothing.
+func addInputs(c *CommandInfo, p *Command, info *InputInfo, src ParsedFrom, rawValue string) error {
+	if info.ValuePolicy == RejectRepeats && src.Opt != "" {
+		if i := p.lastPos(info.ID); i != -1 && p.Inputs[i].From.Opt != "" {
+			return RepeatedOptionError{CmdInfo: c, InputInfo: info}
+		}
+	}
+
+	// Bool options with no value still need to produce a single value.
+	split := info.Separator != "" && !(info.IsBoolOpt && rawValue == "")
+	rest := rawValue
+	replaced := false
+	for more := true; more; {
+		piece := rest
+		if split {
+			piece, rest, more = strings.Cut(rest, info.Separator)
+			if piece == "" {
+				continue
+			}
+		} else {
+			more = false
+		}
+
+		pi, err := newInput(info, src, piece)
+		if err != nil {
+			return newInvalidValueError(c, info, src, piece, err)
+		}
+		if !replaced {
+			replaceValues(p, info, src)
+			replaced = true
+		}
+		if info.IsMapOpt && info.HasUniqueKeys {
+			key := pi.Value.(KeyValue).Key
+			if p.hasKey(info.ID, key, src.tier()) {
+				return DuplicateKeyError{CmdInfo: c, InputInfo: info, Key: key}
+			}
+		}
+		p.addInput(pi)
+	}
+	return nil
+}
+
+// replaceValues removes the values of the given input that a new value from src replaces
+// according to the input's ValuePolicy.
+func replaceValues(p *Command, info *InputInfo, src ParsedFrom) {
+	switch info.ValuePolicy {
+	case ReplaceBySource:
+		// Values are always added in order of their source's tier, so there's only
+		// something to replace if the first value is from a lower tier.
+		if i := p.firstPos(info.ID); i != -1 && p.Inputs[i].From.tier() < src.tier() {
+			p.deleteInputs(info.ID, func(in Input) bool {
+				return in.From.tier() < src.tier()
+			})
+		}
+	case OverrideValues, RejectRepeats:
+		if p.firstPos(info.ID) != -1 {
+			p.deleteInputs(info.ID, func(Input) bool {
+				return true
+			})
+		}
+	}
+}
+
 func newInput(info *InputInfo, src ParsedFrom, rawValue string) (Input, error) {
 	var val any
 	var err error
 
+	// Map options only parse the value part of a key=value pair.
+	valueStr := rawValue
+	var key string
+	if info.IsMapOpt {
+		var ok bool
+		key, valueStr, ok = strings.Cut(rawValue, "=")
+		if !ok || key == "" {
+			return Input{}, errors.New("expected a key=value pair")
+		}
+	}
+
 	switch {
 	// If we have a value parser, use that.
 	case info.ValueParser != nil:
-		val, err = info.ValueParser(rawValue)
+		val, err = info.ValueParser(valueStr)
 	// If we don't have a value parser but we know it's a boolean option, use the
 	// default boolean parser.
 	case info.IsBoolOpt:
@@ -672,23 +1320,109 @@ func newInput(info *InputInfo, src ParsedFrom, rawValue string) (Input, error) {
 		}
 	// No parser, not a bool option, so we just use the raw string.
 	default:
-		val = rawValue
+		val = valueStr
 	}
 
 	if err != nil {
 		return Input{}, err
 	}
 
+	for _, validate := range info.Validators {
+		if err := validate(val); err != nil {
+			return Input{}, err
+		}
+	}
+
+	if info.IsMapOpt {
+		val = KeyValue{Key: key, Value: val}
+	}
+
 	return Input{
 		ID:       info.ID,
 		From:     src,
 		RawValue: rawValue,
 		Value:    val,
+		IsSecret: info.IsSecret,
 	}, nil
 }
 
 var ErrNoSubcmd = errors.New("missing subcommand")
 
+// InvalidValueError is returned when an input's raw value, from whichever source it came
+// from, fails to be parsed by that input's [ValueParser] or is rejected by one of its
+// Validators. The error returned by the value parser or validator is available through
+// Err and errors.Unwrap. If the input is secret, its raw value is redacted from the error
+// message, and Err is replaced with an error that only says the value is invalid unless
+// the original error is known not to include the value (such as [strconv.ErrSyntax]).
+// The replacement still matches the original error with errors.Is.
+type InvalidValueError struct {
+	CmdInfo   *CommandInfo
+	InputInfo *InputInfo
+	From      ParsedFrom
+	RawValue  string
+	Err       error
+}
+
+func newInvalidValueError(c *CommandInfo, in *InputInfo, src ParsedFrom, rawValue string, err error) InvalidValueError {
+	if in.IsSecret && rawValue != "" && err != strconv.ErrSyntax && err != strconv.ErrRange {
+		err = redactedError{err: err}
+	}
+	return InvalidValueError{
+		CmdInfo:   c,
+		InputInfo: in,
+		From:      src,
+		RawValue:  rawValue,
+		Err:       err,
+	}
+}
+
+func (ive InvalidValueError) Error() string {
+	rawValue := ive.RawValue
+	errMsg := ive.Err.Error()
+	if ive.InputInfo.IsSecret {
+		rawValue = redacted
+	}
+
+	switch {
+	case ive.From.Default:
+		kind := "arg"
+		if ive.InputInfo.isOption() {
+			kind = "option"
+		}
+		return fmt.Sprintf("parsing default value '%s' for %s '%s': %s",
+			rawValue, kind, ive.InputInfo.ID, errMsg)
+	case ive.From.Prompt:
+		return fmt.Sprintf("parsing entered value for %s: %s", ive.InputInfo.displayName(), errMsg)
+	case ive.From.Env != "":
+		return fmt.Sprintf("using env var '%s': %s", ive.From.Env, errMsg)
+	case ive.From.Opt != "":
+		return fmt.Sprintf("parsing option '%s': %s", ive.From.Opt, errMsg)
+	default:
+		return fmt.Sprintf("parsing positional argument #%d '%s': %s",
+			ive.From.Arg, rawValue, errMsg)
+	}
+}
+
+func (ive InvalidValueError) Unwrap() error {
+	return ive.Err
+}
+
+// redactedError stands in for the error from parsing or validating a secret value, since
+// there's no telling whether (or in what form) that error includes the value. It doesn't
+// unwrap to the original error, but it does match anything the original does with
+// errors.Is.
+type redactedError struct {
+	err error
+}
+
+func (re redactedError) Error() string {
+	return "invalid value"
+}
+
+func (re redactedError) Is(target error) bool {
+	return errors.Is(re.err, target)
+}
+
 type UnknownSubcmdError struct {
 	CmdInfo *CommandInfo
 	Name    string
@@ -733,6 +1467,136 @@ func (moe MissingOptionsError) Is(err error) bool {
 	return false
 }
 
+// CommandValidationError is returned when the Validator of a [CommandInfo] rejects the
+// parsed [Command]. The error returned by the validator is available through Err and
+// errors.Unwrap.
+type CommandValidationError struct {
+	CmdInfo *CommandInfo
+	Err     error
+}
+
+func (cve CommandValidationError) Error() string {
+	return strings.Join(cve.CmdInfo.Path, " ") + ": " + cve.Err.Error()
+}
+
+func (cve CommandValidationError) Unwrap() error {
+	return cve.Err
+}
+
+// SchemaError describes a single problem with the schema of a command that was found by
+// [CommandInfo.Validate]. Path is the path of the command that has the problem. If the
+// problem is with a specific input, InputKind is either "option" or "positional
+// argument", InputIndex is the input's index in the command's Opts or Args, and InputID
+// is its ID (which might be empty). The default help option that Validate accounts for
+// has an index of len(Opts). InputKind is empty for problems with the command itself.
+type SchemaError struct {
+	Path       []string
+	InputKind  string
+	InputIndex int
+	InputID    string
+	Problem    string
+}
+
+const (
+	kindOption = "option"
+	kindArg    = "positional argument"
+)
+
+func (se SchemaError) Error() string {
+	path := strings.Join(se.Path, " ")
+	switch {
+	case se.InputID != "":
+		return path + ": input '" + se.InputID + "': " + se.Problem
+	case se.InputKind != "":
+		return path + ": " + se.InputKind + " at index " + strconv.Itoa(se.InputIndex) + ": " + se.Problem
+	}
+	return path + ": " + se.Problem
+}
+
+// SchemaErrors is returned by [CommandInfo.Validate] with every problem that it found in
+// the order it found them. Each of the problems can be reached with errors.As.
+type SchemaErrors []SchemaError
+
+func (se SchemaErrors) Error() string {
+	msgs := make([]string, len(se))
+	for i := range se {
+		msgs[i] = se[i].Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (se SchemaErrors) Unwrap() []error {
+	errs := make([]error, len(se))
+	for i := range se {
+		errs[i] = se[i]
+	}
+	return errs
+}
+
+// NotFoundError is returned by [TryGet] when there is no parsed value for an input.
+type NotFoundError struct {
+	ID string
+}
+
+func (nfe NotFoundError) Error() string {
+	return "no parsed input value for id '" + nfe.ID + "'"
+}
+
+// TypeMismatchError is returned by [TryGet] and its siblings when a parsed value isn't
+// of the requested type. Expected and Actual are the names of the requested type and the
+// value's dynamic type (as formatted by the "%T" verb).
+type TypeMismatchError struct {
+	ID       string
+	Expected string
+	Actual   string
+}
+
+func (tme TypeMismatchError) Error() string {
+	return "input '" + tme.ID + "' has a value of type " + tme.Actual + ", not " + tme.Expected
+}
+
+// BindError is returned when the Binder of an input fails to store the input's parsed
+// value(s). The error returned by the Binder is available through Err and errors.Unwrap.
+type BindError struct {
+	CmdInfo   *CommandInfo
+	InputInfo *InputInfo
+	Err       error
+}
+
+func (be BindError) Error() string {
+	return strings.Join(be.CmdInfo.Path, " ") + ": binding '" + be.InputInfo.displayName() +
+		"': " + be.Err.Error()
+}
+
+func (be BindError) Unwrap() error {
+	return be.Err
+}
+
+// DuplicateKeyError is returned when a map option that requires unique keys (see
+// [InputInfo.UniqueKeys]) is given the same key more than once from the same source.
+type DuplicateKeyError struct {
+	CmdInfo   *CommandInfo
+	InputInfo *InputInfo
+	Key       string
+}
+
+func (dke DuplicateKeyError) Error() string {
+	return strings.Join(dke.CmdInfo.Path, " ") + ": option '" + dke.InputInfo.displayName() +
+		"' was given the key '" + dke.Key + "' more than once"
+}
+
+// RepeatedOptionError is returned when an option that has the [RejectRepeats] value
+// policy is provided more than once on the command line.
+type RepeatedOptionError struct {
+	CmdInfo   *CommandInfo
+	InputInfo *InputInfo
+}
+
+func (roe RepeatedOptionError) Error() string {
+	return strings.Join(roe.CmdInfo.Path, " ") + ": option '" + roe.InputInfo.displayName() +
+		"' was given more than once"
+}
+
 type MissingArgsError struct {
 	CmdInfo *CommandInfo
 	Names   []string
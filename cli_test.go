@@ -1,15 +1,27 @@
 package cli
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"image"
+	"io"
+	"io/fs"
+	"log/slog"
+	"math"
+	"math/big"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
 	"reflect"
+	"regexp"
 	"runtime"
 	"slices"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestParsing(t *testing.T) {
@@ -118,6 +130,289 @@ func TestParsing(t *testing.T) {
 				expErrMsg: `using env var 'F64': invalid syntax`,
 			}},
 		}, {
+			// secret values that can't parse are redacted from error messages
+			name: "secret_errors",
+			cmd: New().
+				Opt(NewOpt("tok").Secret().WithParser(NewTimeParser("2006")).Env("TOK")).
+				Arg(NewArg("key").Secret().WithParser(ParseUint)),
+			variations: []testInputOutput{
+				{
+					Case:      ttCase(),
+					envs:      map[string]string{"TOK": "hunter2"},
+					args:      []string{"--", "1"},
+					expErrMsg: `using env var 'TOK': invalid value`,
+				}, {
+					Case:      ttCase(),
+					args:      []string{"--tok", "hunter2", "1"},
+					expErrMsg: `parsing option 'tok': invalid value`,
+				}, {
+					Case:      ttCase(),
+					args:      []string{"--", "hunter2"},
+					expErrMsg: `parsing positional argument #1 '[redacted]': invalid syntax`,
+				},
+			},
+		}, {
+			// secret default value that can't parse
+			name: "secret_default_error",
+			cmd:  New().Arg(NewArg("key").Secret().WithParser(ParseUint).Default("hunter2")),
+			variations: []testInputOutput{{
+				Case:      ttCase(),
+				args:      []string{},
+				expErrMsg: `parsing default value '[redacted]' for arg 'key': invalid syntax`,
+			}},
+		}, {
+			// a required list argument that splits into nothing is missing
+			name: "empty_required_list_arg",
+			cmd:  NewCmd("ids").Arg(NewArg("ids").WithSeparator(",").Required()),
+			variations: []testInputOutput{{
+				Case:      ttCase(),
+				args:      []string{","},
+				expErrMsg: `ids: missing the following required arguments: ids`,
+			}},
+		}, {
+			// list options split on a separator from every source
+			name: "list_opts",
+			cmd: NewCmd("lists").
+				Opt(NewListOpt("host", nil).Env("HOSTS").Default("localhost")).
+				Opt(NewListOpt("port", ParseUint).Env("PORTS").Default("80").WithValuePolicy(AppendValues)).
+				Opt(NewBoolOpt("v").WithSeparator(",")).
+				Arg(NewArg("ids").WithParser(ParseInt).WithSeparator(":")),
+			variations: []testInputOutput{
+				{
+					// values that split into nothing don't replace anything
+					Case: ttCase(),
+					envs: map[string]string{"HOSTS": ","},
+					args: []string{"--host=,", "3"},
+					expected: Command{
+						Inputs: []Input{
+							{ID: "host", From: ParsedFrom{Default: true}, RawValue: "localhost", Value: "localhost"},
+							{ID: "port", From: ParsedFrom{Default: true}, RawValue: "80", Value: uint(80)},
+							{ID: "ids", From: ParsedFrom{Arg: 1}, RawValue: "3", Value: 3},
+						},
+					},
+				}, {
+					Case: ttCase(),
+					envs: map[string]string{"HOSTS": "a,b", "PORTS": "81,82"},
+					args: []string{"-v", "1:2"},
+					expected: Command{
+						Inputs: []Input{
+							{ID: "port", From: ParsedFrom{Default: true}, RawValue: "80", Value: uint(80)},
+							{ID: "host", From: ParsedFrom{Env: "HOSTS"}, RawValue: "a", Value: "a"},
+							{ID: "host", From: ParsedFrom{Env: "HOSTS"}, RawValue: "b", Value: "b"},
+							{ID: "port", From: ParsedFrom{Env: "PORTS"}, RawValue: "81", Value: uint(81)},
+							{ID: "port", From: ParsedFrom{Env: "PORTS"}, RawValue: "82", Value: uint(82)},
+							{ID: "v", From: ParsedFrom{Opt: "v"}, RawValue: "", Value: true},
+							{ID: "ids", From: ParsedFrom{Arg: 1}, RawValue: "1", Value: 1},
+							{ID: "ids", From: ParsedFrom{Arg: 1}, RawValue: "2", Value: 2},
+						},
+					},
+				}, {
+					Case: ttCase(),
+					envs: map[string]string{"HOSTS": "a,b"},
+					args: []string{"--host", "c,d", "--port=90,", "--host=e", "-v=true,false"},
+					expected: Command{
+						Inputs: []Input{
+							{ID: "port", From: ParsedFrom{Default: true}, RawValue: "80", Value: uint(80)},
+							{ID: "host", From: ParsedFrom{Opt: "host"}, RawValue: "c", Value: "c"},
+							{ID: "host", From: ParsedFrom{Opt: "host"}, RawValue: "d", Value: "d"},
+							{ID: "port", From: ParsedFrom{Opt: "port"}, RawValue: "90", Value: uint(90)},
+							{ID: "host", From: ParsedFrom{Opt: "host"}, RawValue: "e", Value: "e"},
+							{ID: "v", From: ParsedFrom{Opt: "v"}, RawValue: "true", Value: true},
+							{ID: "v", From: ParsedFrom{Opt: "v"}, RawValue: "false", Value: false},
+						},
+					},
+				}, {
+					Case:      ttCase(),
+					envs:      map[string]string{"PORTS": "1,x"},
+					args:      []string{},
+					expErrMsg: "using env var 'PORTS': invalid syntax",
+				},
+			},
+		}, func() *testCase {
+			// each value policy with values from a default, an env var, and repeated options
+			tc := testCase{
+				name: "value_policies",
+				cmd: NewCmd("vp").
+					Opt(NewOpt("aa").Short('a').Env("AA").Default("1")).
+					Opt(NewOpt("bb").Short('b').Env("BB").Default("1").WithValuePolicy(ReplaceBySource)).
+					Opt(NewOpt("cc").Short('c').Env("CC").Default("1").WithValuePolicy(OverrideValues)).
+					Opt(NewOpt("dd").Short('d').Env("DD").Default("1").WithValuePolicy(RejectRepeats)),
+			}
+			tc.variations = []testInputOutput{
+				{
+					Case: ttCase(),
+					envs: map[string]string{"AA": "2", "BB": "2", "CC": "2", "DD": "2"},
+					args: []string{"-a3", "-b3", "-c3", "-d3", "-a4", "-b4", "-c4"},
+					expected: Command{
+						Inputs: []Input{
+							{ID: "aa", From: ParsedFrom{Default: true}, RawValue: "1", Value: "1"},
+							{ID: "aa", From: ParsedFrom{Env: "AA"}, RawValue: "2", Value: "2"},
+							{ID: "aa", From: ParsedFrom{Opt: "a"}, RawValue: "3", Value: "3"},
+							{ID: "bb", From: ParsedFrom{Opt: "b"}, RawValue: "3", Value: "3"},
+							{ID: "dd", From: ParsedFrom{Opt: "d"}, RawValue: "3", Value: "3"},
+							{ID: "aa", From: ParsedFrom{Opt: "a"}, RawValue: "4", Value: "4"},
+							{ID: "bb", From: ParsedFrom{Opt: "b"}, RawValue: "4", Value: "4"},
+							{ID: "cc", From: ParsedFrom{Opt: "c"}, RawValue: "4", Value: "4"},
+						},
+					},
+				}, {
+					Case: ttCase(),
+					envs: map[string]string{"BB": "2", "DD": "2"},
+					args: []string{},
+					expected: Command{
+						Inputs: []Input{
+							{ID: "aa", From: ParsedFrom{Default: true}, RawValue: "1", Value: "1"},
+							{ID: "cc", From: ParsedFrom{Default: true}, RawValue: "1", Value: "1"},
+							{ID: "bb", From: ParsedFrom{Env: "BB"}, RawValue: "2", Value: "2"},
+							{ID: "dd", From: ParsedFrom{Env: "DD"}, RawValue: "2", Value: "2"},
+						},
+					},
+				}, {
+					Case:   ttCase(),
+					args:   []string{"-d3", "--dd", "4"},
+					expErr: RepeatedOptionError{CmdInfo: &tc.cmd, InputInfo: &tc.cmd.Opts[3]},
+				}, {
+					Case:      ttCase(),
+					args:      []string{"-d3", "-d3"},
+					expErrMsg: "vp: option '--dd' was given more than once",
+				},
+			}
+			return &tc
+		}(), func() *testCase {
+			// map options from every source, with and without separators
+			tc := testCase{
+				name: "map_opts",
+				cmd: NewCmd("maps").
+					Opt(NewMapOpt("label", nil).Short('l').Env("LABELS").WithSeparator(",").Default("a=0")).
+					Opt(NewMapOpt("limit", ParseInt).UniqueKeys()),
+			}
+			tc.variations = []testInputOutput{
+				{
+					Case: ttCase(),
+					envs: map[string]string{"LABELS": "a=1,,b=x=y"},
+					args: []string{"-l", "c=", "--limit", "n=3", "--label=d=4,a=5"},
+					expected: Command{
+						Inputs: []Input{
+							{ID: "label", From: ParsedFrom{Default: true}, RawValue: "a=0", Value: KeyValue{"a", "0"}},
+							{ID: "label", From: ParsedFrom{Env: "LABELS"}, RawValue: "a=1", Value: KeyValue{"a", "1"}},
+							{ID: "label", From: ParsedFrom{Env: "LABELS"}, RawValue: "b=x=y", Value: KeyValue{"b", "x=y"}},
+							{ID: "label", From: ParsedFrom{Opt: "l"}, RawValue: "c=", Value: KeyValue{"c", ""}},
+							{ID: "limit", From: ParsedFrom{Opt: "limit"}, RawValue: "n=3", Value: KeyValue{"n", 3}},
+							{ID: "label", From: ParsedFrom{Opt: "label"}, RawValue: "d=4", Value: KeyValue{"d", "4"}},
+							{ID: "label", From: ParsedFrom{Opt: "label"}, RawValue: "a=5", Value: KeyValue{"a", "5"}},
+						},
+					},
+				}, {
+					Case:      ttCase(),
+					args:      []string{"--label", "novalue"},
+					expErrMsg: "parsing option 'label': expected a key=value pair",
+				}, {
+					Case:      ttCase(),
+					args:      []string{"--limit", "=3"},
+					expErrMsg: "parsing option 'limit': expected a key=value pair",
+				}, {
+					Case:      ttCase(),
+					args:      []string{"--limit", "n=x"},
+					expErrMsg: "parsing option 'limit': invalid syntax",
+				}, {
+					Case:   ttCase(),
+					args:   []string{"--limit", "n=1", "--limit", "m=2", "--limit", "n=3"},
+					expErr: DuplicateKeyError{CmdInfo: &tc.cmd, InputInfo: &tc.cmd.Opts[1], Key: "n"},
+				},
+			}
+			return &tc
+		}(), {
+			// validators run on values from every source
+			name: "validators",
+			cmd: NewCmd("val").
+				Opt(NewIntOpt("port").Env("PORT").Default("8080").WithValidators(InRange(1, 65535))).
+				Opt(NewOpt("mode").WithValidators(NotEmpty, OneOf("fast", "slow"))).
+				Opt(NewMapOpt("lim", ParseUint).WithValidators(InRange[uint](1, 9))).
+				Opt(NewOpt("name").WithValidators(MatchesRegexp(regexp.MustCompile(`^[a-z]+$`)))).
+				Opt(NewOpt("file").WithValidators(FileExists)).
+				Opt(NewOpt("dir").WithValidators(DirExists)),
+			variations: []testInputOutput{
+				{
+					Case: ttCase(),
+					args: []string{"--mode=slow", "--lim", "a=9", "--name=ab", "--file=testdata/sample_int", "--dir=testdata"},
+					expected: Command{
+						Inputs: []Input{
+							{ID: "port", From: ParsedFrom{Default: true}, RawValue: "8080", Value: 8080},
+							{ID: "mode", From: ParsedFrom{Opt: "mode"}, RawValue: "slow", Value: "slow"},
+							{ID: "lim", From: ParsedFrom{Opt: "lim"}, RawValue: "a=9", Value: KeyValue{"a", uint(9)}},
+							{ID: "name", From: ParsedFrom{Opt: "name"}, RawValue: "ab", Value: "ab"},
+							{ID: "file", From: ParsedFrom{Opt: "file"}, RawValue: "testdata/sample_int", Value: "testdata/sample_int"},
+							{ID: "dir", From: ParsedFrom{Opt: "dir"}, RawValue: "testdata", Value: "testdata"},
+						},
+					},
+				},
+				{Case: ttCase(), envs: map[string]string{"PORT": "0"}, expErrMsg: "using env var 'PORT': must be between 1 and 65535"},
+				{Case: ttCase(), args: []string{"--port", "70000"}, expErrMsg: "parsing option 'port': must be between 1 and 65535"},
+				{Case: ttCase(), args: []string{"--mode="}, expErrMsg: "parsing option 'mode': must not be empty"},
+				{Case: ttCase(), args: []string{"--mode=x"}, expErrMsg: "parsing option 'mode': must be one of [fast slow]"},
+				{Case: ttCase(), args: []string{"--lim=a=0"}, expErrMsg: "parsing option 'lim': must be between 1 and 9"},
+				{Case: ttCase(), args: []string{"--name=A"}, expErrMsg: "parsing option 'name': must match the pattern '^[a-z]+$'"},
+				{Case: ttCase(), args: []string{"--file=testdata"}, expErrMsg: "parsing option 'file': 'testdata' is a directory"},
+				{Case: ttCase(), args: []string{"--dir=testdata/sample_int"}, expErrMsg: "parsing option 'dir': 'testdata/sample_int' is not a directory"},
+				{Case: ttCase(), args: []string{"--dir=nope"}, expErrMsg: "parsing option 'dir': stat nope: no such file or directory"},
+			},
+		}, func() *testCase {
+			// command validators run on each parsed command level after everything parses
+			errMinMax := errors.New("--min cannot be more than --max")
+			tc := testCase{
+				name: "command_validators",
+				cmd: NewCmd("cv").
+					Opt(NewIntOpt("min").Default("0")).
+					Opt(NewIntOpt("max").Default("10")).
+					WithValidator(func(c *Command) error {
+						if Get[int](c, "min") > Get[int](c, "max") {
+							return errMinMax
+						}
+						return nil
+					}).
+					Subcmd(NewCmd("sc").
+						Opt(NewBoolOpt("a").Help("first")).
+						Opt(NewBoolOpt("b").Help("second")).
+						WithValidator(func(c *Command) error {
+							if GetOr(c, "a", false) && GetOr(c, "b", false) {
+								return errors.New("-a and -b are mutually exclusive")
+							}
+							return nil
+						})),
+			}
+			tc.variations = []testInputOutput{
+				{
+					Case: ttCase(),
+					args: []string{"--min=3", "sc", "-a"},
+					expected: Command{
+						Inputs: []Input{
+							{ID: "min", From: ParsedFrom{Default: true}, RawValue: "0", Value: 0},
+							{ID: "max", From: ParsedFrom{Default: true}, RawValue: "10", Value: 10},
+							{ID: "min", From: ParsedFrom{Opt: "min"}, RawValue: "3", Value: 3},
+						},
+						Subcmd: &Command{
+							Name: "sc",
+							Inputs: []Input{
+								{ID: "a", From: ParsedFrom{Opt: "a"}, RawValue: "", Value: true},
+							},
+						},
+					},
+				}, {
+					Case:   ttCase(),
+					args:   []string{"--min=11", "sc", "-ab"},
+					expErr: CommandValidationError{CmdInfo: &tc.cmd, Err: errMinMax},
+				}, {
+					Case:      ttCase(),
+					args:      []string{"sc", "-ab"},
+					expErrMsg: "cv sc: -a and -b are mutually exclusive",
+				}, {
+					Case:   ttCase(),
+					args:   []string{"--min=11", "sc", "-h"},
+					expErr: HelpOrVersionRequested{Msg: "cv sc\n\nusage:\n  sc [options]\n\noptions:\n  -a           first\n  -b           second\n  -h, --help   Show this help message and exit.\n"},
+				},
+			}
+			return &tc
+		}(), {
 			// all provided parsers with defaults
 			name: "provided_parsers",
 			cmd: NewCmd("pp").
@@ -186,6 +481,241 @@ func TestParsing(t *testing.T) {
 					},
 				},
 			},
+		}, {
+			// sized integer parsers and big number parsers
+			name: "sized_number_parsers",
+			cmd: NewCmd("snp").
+				Opt(NewInt8Opt("i8")).
+				Opt(NewInt16Opt("i16")).
+				Opt(NewInt32Opt("i32")).
+				Opt(NewInt64Opt("i64")).
+				Opt(NewUint8Opt("u8")).
+				Opt(NewUint16Opt("u16")).
+				Opt(NewUint32Opt("u32")).
+				Opt(NewUint64Opt("u64")).
+				Opt(NewBigIntOpt("bi")).
+				Opt(NewBigFloatOpt("bf")).
+				Opt(NewBigRatOpt("br")),
+			variations: []testInputOutput{
+				{
+					Case: ttCase(),
+					args: []string{
+						"--i8=-128", "--i16=0x7fff", "--i32=-2147483648", "--i64=9223372036854775807",
+						"--u8=255", "--u16=65535", "--u32=0o37777777777", "--u64=18446744073709551615",
+						"--bi=0x10000000000000000", "--bf=1.5e1000", "--br=3/4",
+					},
+					expected: Command{
+						Inputs: []Input{
+							{ID: "i8", From: ParsedFrom{Opt: "i8"}, RawValue: "-128", Value: int8(-128)},
+							{ID: "i16", From: ParsedFrom{Opt: "i16"}, RawValue: "0x7fff", Value: int16(32767)},
+							{ID: "i32", From: ParsedFrom{Opt: "i32"}, RawValue: "-2147483648", Value: int32(-2147483648)},
+							{ID: "i64", From: ParsedFrom{Opt: "i64"}, RawValue: "9223372036854775807", Value: int64(9223372036854775807)},
+							{ID: "u8", From: ParsedFrom{Opt: "u8"}, RawValue: "255", Value: uint8(255)},
+							{ID: "u16", From: ParsedFrom{Opt: "u16"}, RawValue: "65535", Value: uint16(65535)},
+							{ID: "u32", From: ParsedFrom{Opt: "u32"}, RawValue: "0o37777777777", Value: uint32(4294967295)},
+							{ID: "u64", From: ParsedFrom{Opt: "u64"}, RawValue: "18446744073709551615", Value: uint64(18446744073709551615)},
+							{ID: "bi", From: ParsedFrom{Opt: "bi"}, RawValue: "0x10000000000000000", Value: new(big.Int).Lsh(big.NewInt(1), 64)},
+							{ID: "bf", From: ParsedFrom{Opt: "bf"}, RawValue: "1.5e1000", Value: func() *big.Float { f, _ := new(big.Float).SetString("1.5e1000"); return f }()},
+							{ID: "br", From: ParsedFrom{Opt: "br"}, RawValue: "3/4", Value: big.NewRat(3, 4)},
+						},
+					},
+				},
+				{Case: ttCase(), args: []string{"--i8=128"}, expErrMsg: "parsing option 'i8': value out of range"},
+				{Case: ttCase(), args: []string{"--i16=-32769"}, expErrMsg: "parsing option 'i16': value out of range"},
+				{Case: ttCase(), args: []string{"--i32=2147483648"}, expErrMsg: "parsing option 'i32': value out of range"},
+				{Case: ttCase(), args: []string{"--i64=9223372036854775808"}, expErrMsg: "parsing option 'i64': value out of range"},
+				{Case: ttCase(), args: []string{"--u8=256"}, expErrMsg: "parsing option 'u8': value out of range"},
+				{Case: ttCase(), args: []string{"--u16=65536"}, expErrMsg: "parsing option 'u16': value out of range"},
+				{Case: ttCase(), args: []string{"--u32=4294967296"}, expErrMsg: "parsing option 'u32': value out of range"},
+				{Case: ttCase(), args: []string{"--u64=18446744073709551616"}, expErrMsg: "parsing option 'u64': value out of range"},
+				{Case: ttCase(), args: []string{"--u8=-1"}, expErrMsg: "parsing option 'u8': invalid syntax"},
+				{Case: ttCase(), args: []string{"--bi=1.5"}, expErrMsg: "parsing option 'bi': invalid integer value"},
+				{Case: ttCase(), args: []string{"--bf=x"}, expErrMsg: "parsing option 'bf': invalid floating point value"},
+				{Case: ttCase(), args: []string{"--br=1/0"}, expErrMsg: "parsing option 'br': invalid rational value"},
+			},
+		}, {
+			name: "byte_sizes",
+			cmd:  NewCmd("bs").Opt(NewByteSizeOpt("sz").Short('s')),
+			variations: []testInputOutput{
+				{
+					Case: ttCase(),
+					args: []string{"-s", "512", "-s", "12b", "-s", "10MiB", "-s", "1.5G", "-s", "512k", "-s", "2 kib", "-s", "3Ti", "-s", "0.5KB"},
+					expected: Command{
+						Inputs: []Input{
+							{ID: "sz", From: ParsedFrom{Opt: "s"}, RawValue: "512", Value: uint64(512)},
+							{ID: "sz", From: ParsedFrom{Opt: "s"}, RawValue: "12b", Value: uint64(12)},
+							{ID: "sz", From: ParsedFrom{Opt: "s"}, RawValue: "10MiB", Value: uint64(10 << 20)},
+							{ID: "sz", From: ParsedFrom{Opt: "s"}, RawValue: "1.5G", Value: uint64(1_500_000_000)},
+							{ID: "sz", From: ParsedFrom{Opt: "s"}, RawValue: "512k", Value: uint64(512_000)},
+							{ID: "sz", From: ParsedFrom{Opt: "s"}, RawValue: "2 kib", Value: uint64(2048)},
+							{ID: "sz", From: ParsedFrom{Opt: "s"}, RawValue: "3Ti", Value: uint64(3 << 40)},
+							{ID: "sz", From: ParsedFrom{Opt: "s"}, RawValue: "0.5KB", Value: uint64(500)},
+						},
+					},
+				},
+				{Case: ttCase(), args: []string{"-s", "16EiB"}, expErrMsg: "parsing option 's': value out of range"},
+				{Case: ttCase(), args: []string{"-s", "18446744073709551615"}, expected: Command{Inputs: []Input{{ID: "sz", From: ParsedFrom{Opt: "s"}, RawValue: "18446744073709551615", Value: uint64(18446744073709551615)}}}},
+				{Case: ttCase(), args: []string{"-s", "15.9999999999EiB"}, expected: Command{Inputs: []Input{{ID: "sz", From: ParsedFrom{Opt: "s"}, RawValue: "15.9999999999EiB", Value: uint64(18446744073594259465)}}}},
+				{Case: ttCase(), args: []string{"-s", "18446744073709551616"}, expErrMsg: "parsing option 's': value out of range"},
+				{Case: ttCase(), args: []string{"-s", "20000P"}, expErrMsg: "parsing option 's': value out of range"},
+				{Case: ttCase(), args: []string{"-s", "10 parsecs"}, expErrMsg: "parsing option 's': unknown byte size unit ' parsecs'"},
+				{Case: ttCase(), args: []string{"-s", "1.2.3M"}, expErrMsg: "parsing option 's': invalid byte size"},
+				{Case: ttCase(), args: []string{"-s", "-1M"}, expErrMsg: "parsing option 's': invalid byte size"},
+				{Case: ttCase(), args: []string{"-s", "MiB"}, expErrMsg: "parsing option 's': invalid byte size"},
+			},
+		}, {
+			name: "extended_durations",
+			cmd:  NewCmd("ed").Opt(NewExtendedDurationOpt("ttl").Short('t')),
+			variations: []testInputOutput{
+				{
+					Case: ttCase(),
+					args: []string{"-t", "7d", "-t", "2w", "-t", "1w2d12h30m", "-t", "1.5d", "-t", "-3d", "-t", "90m", "-t", "0", "-t", "1h2m3.5s", "-t", "5µs"},
+					expected: Command{
+						Inputs: []Input{
+							{ID: "ttl", From: ParsedFrom{Opt: "t"}, RawValue: "7d", Value: 7 * 24 * time.Hour},
+							{ID: "ttl", From: ParsedFrom{Opt: "t"}, RawValue: "2w", Value: 14 * 24 * time.Hour},
+							{ID: "ttl", From: ParsedFrom{Opt: "t"}, RawValue: "1w2d12h30m", Value: 228*time.Hour + 30*time.Minute},
+							{ID: "ttl", From: ParsedFrom{Opt: "t"}, RawValue: "1.5d", Value: 36 * time.Hour},
+							{ID: "ttl", From: ParsedFrom{Opt: "t"}, RawValue: "-3d", Value: -72 * time.Hour},
+							{ID: "ttl", From: ParsedFrom{Opt: "t"}, RawValue: "90m", Value: 90 * time.Minute},
+							{ID: "ttl", From: ParsedFrom{Opt: "t"}, RawValue: "0", Value: time.Duration(0)},
+							{ID: "ttl", From: ParsedFrom{Opt: "t"}, RawValue: "1h2m3.5s", Value: time.Hour + 2*time.Minute + 3500*time.Millisecond},
+							{ID: "ttl", From: ParsedFrom{Opt: "t"}, RawValue: "5µs", Value: 5 * time.Microsecond},
+						},
+					},
+				},
+				{
+					Case: ttCase(),
+					args: []string{"-t", "P1DT2H", "-t", "PT1.5S", "-t", "P2W", "-t", "-PT30M", "-t", "p1dt0,5h"},
+					expected: Command{
+						Inputs: []Input{
+							{ID: "ttl", From: ParsedFrom{Opt: "t"}, RawValue: "P1DT2H", Value: 26 * time.Hour},
+							{ID: "ttl", From: ParsedFrom{Opt: "t"}, RawValue: "PT1.5S", Value: 1500 * time.Millisecond},
+							{ID: "ttl", From: ParsedFrom{Opt: "t"}, RawValue: "P2W", Value: 14 * 24 * time.Hour},
+							{ID: "ttl", From: ParsedFrom{Opt: "t"}, RawValue: "-PT30M", Value: -30 * time.Minute},
+							{ID: "ttl", From: ParsedFrom{Opt: "t"}, RawValue: "p1dt0,5h", Value: 24*time.Hour + 30*time.Minute},
+						},
+					},
+				},
+				{Case: ttCase(), args: []string{"-t", "7"}, expErrMsg: "parsing option 't': invalid duration '7': missing unit"},
+				{Case: ttCase(), args: []string{"-t", "7y"}, expErrMsg: "parsing option 't': invalid duration '7y': unknown unit 'y'"},
+				{Case: ttCase(), args: []string{"-t", "d"}, expErrMsg: "parsing option 't': invalid duration 'd': missing number"},
+				{Case: ttCase(), args: []string{"-t", ""}, expErrMsg: "parsing option 't': invalid duration '': empty duration"},
+				{Case: ttCase(), args: []string{"-t", "1.2.3h"}, expErrMsg: "parsing option 't': invalid duration '1.2.3h': missing number"},
+				{Case: ttCase(), args: []string{"-t", "20000w"}, expErrMsg: "parsing option 't': invalid duration '20000w': value out of range"},
+				{Case: ttCase(), args: []string{"-t", "P1Y"}, expErrMsg: "parsing option 't': invalid duration 'P1Y': years and months are not supported"},
+				{Case: ttCase(), args: []string{"-t", "P1M"}, expErrMsg: "parsing option 't': invalid duration 'P1M': years and months are not supported"},
+				{Case: ttCase(), args: []string{"-t", "PT1D"}, expErrMsg: "parsing option 't': invalid duration 'PT1D': unexpected 'D'"},
+				{Case: ttCase(), args: []string{"-t", "P1DT"}, expErrMsg: "parsing option 't': invalid duration 'P1DT': misplaced 'T'"},
+				{Case: ttCase(), args: []string{"-t", "P"}, expErrMsg: "parsing option 't': invalid duration 'P': empty duration"},
+				{Case: ttCase(), args: []string{"-t", "P5"}, expErrMsg: "parsing option 't': invalid duration 'P5': missing designator"},
+			},
+		}, {
+			name: "network_parsers",
+			cmd: NewCmd("net").
+				Opt(NewAddrOpt("addr")).
+				Opt(NewAddrOpt("addr4").WithValidators(IPv4Only)).
+				Opt(NewPrefixOpt("allow").WithValidators(IPv6Only)).
+				Opt(NewAddrPortOpt("bind")).
+				Opt(NewMACOpt("mac")).
+				Opt(NewOpt("listen").WithParser(NewHostPortParser(8080))).
+				Opt(NewOpt("upstream").WithParser(NewHostPortParser())).
+				Opt(NewOpt("any").WithParser(NewHostPortParser(0))),
+			variations: []testInputOutput{
+				{
+					Case: ttCase(),
+					args: []string{
+						"--addr", "fe80::1%eth0", "--addr4", "10.1.2.3", "--allow", "2001:db8::/32",
+						"--bind", "[::1]:443", "--mac", "00:00:5e:00:53:01", "--upstream", "example.com:80",
+						"--listen", "localhost", "--listen", ":9090", "--listen", "::1", "--listen", "[::1]", "--listen", "[::1]:1",
+						"--listen", "localhost:", "--any", "127.0.0.1", "--any", "[::1]:",
+					},
+					expected: Command{
+						Inputs: []Input{
+							{ID: "addr", From: ParsedFrom{Opt: "addr"}, RawValue: "fe80::1%eth0", Value: netip.MustParseAddr("fe80::1%eth0")},
+							{ID: "addr4", From: ParsedFrom{Opt: "addr4"}, RawValue: "10.1.2.3", Value: netip.MustParseAddr("10.1.2.3")},
+							{ID: "allow", From: ParsedFrom{Opt: "allow"}, RawValue: "2001:db8::/32", Value: netip.MustParsePrefix("2001:db8::/32")},
+							{ID: "bind", From: ParsedFrom{Opt: "bind"}, RawValue: "[::1]:443", Value: netip.MustParseAddrPort("[::1]:443")},
+							{ID: "mac", From: ParsedFrom{Opt: "mac"}, RawValue: "00:00:5e:00:53:01", Value: net.HardwareAddr{0, 0, 0x5e, 0, 0x53, 1}},
+							{ID: "upstream", From: ParsedFrom{Opt: "upstream"}, RawValue: "example.com:80", Value: "example.com:80"},
+							{ID: "listen", From: ParsedFrom{Opt: "listen"}, RawValue: "localhost", Value: "localhost:8080"},
+							{ID: "listen", From: ParsedFrom{Opt: "listen"}, RawValue: ":9090", Value: ":9090"},
+							{ID: "listen", From: ParsedFrom{Opt: "listen"}, RawValue: "::1", Value: "[::1]:8080"},
+							{ID: "listen", From: ParsedFrom{Opt: "listen"}, RawValue: "[::1]", Value: "[::1]:8080"},
+							{ID: "listen", From: ParsedFrom{Opt: "listen"}, RawValue: "[::1]:1", Value: "[::1]:1"},
+							{ID: "listen", From: ParsedFrom{Opt: "listen"}, RawValue: "localhost:", Value: "localhost:8080"},
+							{ID: "any", From: ParsedFrom{Opt: "any"}, RawValue: "127.0.0.1", Value: "127.0.0.1:0"},
+							{ID: "any", From: ParsedFrom{Opt: "any"}, RawValue: "[::1]:", Value: "[::1]:0"},
+						},
+					},
+				},
+				{Case: ttCase(), args: []string{"--addr", "10.0.0.256"}, expErrMsg: `parsing option 'addr': ParseAddr("10.0.0.256"): IPv4 field has value >255`},
+				{Case: ttCase(), args: []string{"--addr4", "::1"}, expErrMsg: "parsing option 'addr4': must be an IPv4 address"},
+				{Case: ttCase(), args: []string{"--allow", "10.0.0.0/8"}, expErrMsg: "parsing option 'allow': must be an IPv6 address"},
+				{Case: ttCase(), args: []string{"--bind", "localhost:80"}, expErrMsg: `parsing option 'bind': ParseAddr("localhost"): unable to parse IP`},
+				{Case: ttCase(), args: []string{"--mac", "00:00"}, expErrMsg: "parsing option 'mac': address 00:00: invalid MAC address"},
+				{Case: ttCase(), args: []string{"--listen", "localhost:http"}, expErrMsg: "parsing option 'listen': invalid port 'http'"},
+				{Case: ttCase(), args: []string{"--listen", "localhost:65536"}, expErrMsg: "parsing option 'listen': invalid port '65536'"},
+				{Case: ttCase(), args: []string{"--listen", "[::1"}, expErrMsg: "parsing option 'listen': address [::1: missing ']' in address"},
+				{Case: ttCase(), args: []string{"--upstream", "example.com"}, expErrMsg: "parsing option 'upstream': address example.com: missing port in address"},
+				{Case: ttCase(), args: []string{"--upstream", "example.com:"}, expErrMsg: "parsing option 'upstream': invalid port ''"},
+			},
+		}, {
+			name: "text_parsers",
+			cmd: NewCmd("text").
+				Opt(NewTextOpt[slog.Level]("level")).
+				Opt(NewOpt("addr").WithParser(ParseText[netip.Addr])).
+				Opt(NewTextOpt[testTemperature]("temp").Default("20C")).
+				Opt(NewTextPtrOpt[big.Int]("count")),
+			variations: []testInputOutput{
+				{
+					Case: ttCase(),
+					args: []string{"--level", "warn+2", "--addr", "192.0.2.1", "--temp", "-4C", "--count", "0x10000000000000000"},
+					expected: Command{
+						Inputs: []Input{
+							{ID: "temp", From: ParsedFrom{Default: true}, RawValue: "20C", Value: testTemperature(20)},
+							{ID: "level", From: ParsedFrom{Opt: "level"}, RawValue: "warn+2", Value: slog.LevelWarn + 2},
+							{ID: "addr", From: ParsedFrom{Opt: "addr"}, RawValue: "192.0.2.1", Value: netip.MustParseAddr("192.0.2.1")},
+							{ID: "temp", From: ParsedFrom{Opt: "temp"}, RawValue: "-4C", Value: testTemperature(-4)},
+							{ID: "count", From: ParsedFrom{Opt: "count"}, RawValue: "0x10000000000000000", Value: new(big.Int).Lsh(big.NewInt(1), 64)},
+						},
+					},
+				},
+				{Case: ttCase(), args: []string{"--level", "loud"}, expErrMsg: `parsing option 'level': slog: level string "loud": unknown name`},
+				{Case: ttCase(), args: []string{"--temp", "70F"}, expErrMsg: `parsing option 'temp': temperature must be in Celsius (e.g. "20C")`},
+				{Case: ttCase(), args: []string{"--count", "zz"}, expErrMsg: `parsing option 'count': math/big: cannot unmarshal "zz" into a *big.Int`},
+			},
+		}, {
+			name: "pattern_parsers",
+			cmd: NewCmd("patterns").
+				Opt(NewOpt("include").WithParser(ParseGlob)).
+				Opt(NewOpt("exclude").WithParser(ParseFilepathGlob)).
+				Opt(NewOpt("match").WithParser(ParseRegexp)).
+				Opt(NewOpt("selector").WithParser(ParseJSON[map[string]string])).
+				Opt(NewOpt("point").WithParser(ParseJSON[image.Point])),
+			variations: []testInputOutput{
+				{
+					Case: ttCase(),
+					args: []string{
+						"--include", "src/*.go", "--exclude", "*_test.go", "--match", `^v\d+$`,
+						"--selector", `{"app":"web","tier":"frontend"}`, "--point", `{"X":1,"Y":2}`,
+					},
+					expected: Command{
+						Inputs: []Input{
+							{ID: "include", From: ParsedFrom{Opt: "include"}, RawValue: "src/*.go", Value: "src/*.go"},
+							{ID: "exclude", From: ParsedFrom{Opt: "exclude"}, RawValue: "*_test.go", Value: "*_test.go"},
+							{ID: "match", From: ParsedFrom{Opt: "match"}, RawValue: `^v\d+$`, Value: regexp.MustCompile(`^v\d+$`)},
+							{ID: "selector", From: ParsedFrom{Opt: "selector"}, RawValue: `{"app":"web","tier":"frontend"}`, Value: map[string]string{"app": "web", "tier": "frontend"}},
+							{ID: "point", From: ParsedFrom{Opt: "point"}, RawValue: `{"X":1,"Y":2}`, Value: image.Pt(1, 2)},
+						},
+					},
+				},
+				{Case: ttCase(), args: []string{"--include", "src/[a-"}, expErrMsg: "parsing option 'include': syntax error in pattern"},
+				{Case: ttCase(), args: []string{"--exclude", `[\]`}, expErrMsg: "parsing option 'exclude': syntax error in pattern"},
+				{Case: ttCase(), args: []string{"--match", "a(b"}, expErrMsg: "parsing option 'match': error parsing regexp: missing closing ): `a(b`"},
+				{Case: ttCase(), args: []string{"--selector", `["app"]`}, expErrMsg: "parsing option 'selector': json: cannot unmarshal array into Go value of type map[string]string"},
+				{Case: ttCase(), args: []string{"--point", `{"X":1}x`}, expErrMsg: "parsing option 'point': invalid character 'x' after top-level value"},
+			},
 		}, func() *testCase {
 			// positional arg stuff
 			// all required args but not all optional ones
@@ -690,6 +1220,70 @@ func TestParsing(t *testing.T) {
 	}
 }
 
+// allocCases are common parsing cases along with how many allocations they should take.
+// Parsing any of them takes one allocation for the Command and one for its Inputs, plus
+// one for each string or int value that needs to be boxed to be stored in an Input (bools
+// and ints from 0 to 255 don't). A subcommand takes the same again, and cases that need
+// the Inputs to grow take one more.
+var allocCases = []struct {
+	name   string
+	args   []string
+	allocs float64
+}{
+	{name: "none", args: []string{}, allocs: 2},
+	{name: "bools", args: []string{"-v", "--quiet", "--verbose=false"}, allocs: 2},
+	{name: "stacked_bools", args: []string{"-vq", "-qv"}, allocs: 3},
+	{name: "strings", args: []string{"--name", "a", "--name=b", "-nc", "-nd"}, allocs: 6},
+	{name: "ints", args: []string{"--count", "5000", "--count=70000", "-c", "-300", "-c1234"}, allocs: 6},
+	{name: "small_ints", args: []string{"--count", "5", "-c", "255"}, allocs: 2},
+	{name: "mixed", args: []string{"-vq", "--name", "joe", "--count", "5000"}, allocs: 4},
+	{name: "subcommand", args: []string{"-v", "sub", "--name", "x", "--count", "1000", "f.txt"}, allocs: 7},
+}
+
+func newAllocsCmd() CommandInfo {
+	return NewCmd("app").
+		Opt(NewBoolOpt("verbose").Short('v')).
+		Opt(NewBoolOpt("quiet").Short('q')).
+		Opt(NewOpt("name").Short('n')).
+		Opt(NewIntOpt("count").Short('c').Default("1")).
+		SubcmdOptional().
+		Subcmd(NewCmd("sub").
+			Opt(NewOpt("name")).
+			Opt(NewIntOpt("count").Default("10")).
+			Arg(NewArg("file")))
+}
+
+func TestParsingAllocs(t *testing.T) {
+	in := newAllocsCmd()
+	for _, tc := range allocCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := in.ParseThese(tc.args...); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := testing.AllocsPerRun(100, func() {
+				_, _ = in.ParseThese(tc.args...)
+			})
+			if got > tc.allocs {
+				t.Errorf("expected at most %v allocations, got %v", tc.allocs, got)
+			}
+		})
+	}
+}
+
+func BenchmarkParsing(b *testing.B) {
+	in := newAllocsCmd()
+	for _, tc := range allocCases {
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				if _, err := in.ParseThese(tc.args...); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 func cmpParsed(t *testing.T, tioInfo string, exp, got *Command) {
 	t.Helper()
 
@@ -726,6 +1320,9 @@ func cmpParsed(t *testing.T, tioInfo string, exp, got *Command) {
 		case got.Subcmd != nil && exp.Subcmd == nil:
 			t.Errorf("%s:\ndid not expect a subcommand\ngot %+v", tioInfo, got.Subcmd)
 		case got.Subcmd != nil && exp.Subcmd != nil:
+			if got.Subcmd.Parent != got {
+				t.Errorf("%s: subcommand '%s' isn't linked to its parent", tioInfo, got.Subcmd.Name)
+			}
 			cmpParsed(t, tioInfo, exp.Subcmd, got.Subcmd)
 		}
 	}
@@ -781,34 +1378,1287 @@ func TestCmpErrors(t *testing.T) {
 	}
 }
 
-func TestOptLookups(t *testing.T) {
-	in := NewCmd("program").
-		Opt(NewOpt("a").Required()).
-		Opt(NewOpt("b"))
+// testTemperature is a domain type that implements [encoding.TextUnmarshaler] and
+// [encoding.TextMarshaler] for testing the text value parser and formatter.
+type testTemperature int
 
-	// with both options present
-	{
-		c := in.ParseTheseOrExit("-ahello", "-bworld")
-		// straight getting the opts
+func (t *testTemperature) UnmarshalText(b []byte) error {
+	s, ok := strings.CutSuffix(string(b), "C")
+	if !ok {
+		return errors.New(`temperature must be in Celsius (e.g. "20C")`)
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*t = testTemperature(n)
+	return nil
+}
+
+func (t testTemperature) MarshalText() ([]byte, error) {
+	return []byte(strconv.Itoa(int(t)) + "°C"), nil
+}
+
+func TestInvalidValueError(t *testing.T) {
+	in := NewCmd("ive").
+		Opt(NewIntOpt("aa").Env("AA")).
+		Subcmd(NewCmd("sc").
+			Arg(NewArg("arg1").WithParser(ParseUint)))
+
+	for _, tt := range []struct {
+		Case     string
+		envs     map[string]string
+		args     []string
+		expCmd   []string
+		expID    string
+		expFrom  ParsedFrom
+		expRaw   string
+		expInner error
+	}{
 		{
-			optA := Get[string](c, "a")
-			if optA != "hello" {
-				t.Errorf(`expected "hello" for option a, got "%s"`, optA)
+			Case:     ttCase(),
+			args:     []string{"--aa", "x", "sc"},
+			expCmd:   []string{"ive"},
+			expID:    "aa",
+			expFrom:  ParsedFrom{Opt: "aa"},
+			expRaw:   "x",
+			expInner: strconv.ErrSyntax,
+		}, {
+			Case:     ttCase(),
+			envs:     map[string]string{"AA": "y"},
+			args:     []string{"sc"},
+			expCmd:   []string{"ive"},
+			expID:    "aa",
+			expFrom:  ParsedFrom{Env: "AA"},
+			expRaw:   "y",
+			expInner: strconv.ErrSyntax,
+		}, {
+			Case:     ttCase(),
+			args:     []string{"sc", "--", "-1"},
+			expCmd:   []string{"ive", "sc"},
+			expID:    "arg1",
+			expFrom:  ParsedFrom{Arg: 1},
+			expRaw:   "-1",
+			expInner: strconv.ErrSyntax,
+		},
+	} {
+		t.Run(tt.Case, func(t *testing.T) {
+			for k, v := range tt.envs {
+				t.Setenv(k, v)
 			}
-			optB := Get[string](c, "b")
-			if optB != "world" {
-				t.Errorf(`expected "world" for option b, got "%s"`, optB)
+			_, err := in.ParseThese(tt.args...)
+
+			var ive InvalidValueError
+			if !errors.As(err, &ive) {
+				t.Fatalf("%s: expected an InvalidValueError, got %[2]T: %[2]v", tt.Case, err)
 			}
-		}
-		// above should be identical with lookups
-		{
-			optA, ok := Lookup[string](c, "a")
-			if optA != "hello" || !ok {
-				t.Errorf(`expected ("hello", true) for option a lookup, got ("%s", %v)`, optA, ok)
+			if !slices.Equal(ive.CmdInfo.Path, tt.expCmd) {
+				t.Errorf("%s: expected command path %v, got %v", tt.Case, tt.expCmd, ive.CmdInfo.Path)
 			}
-			optB, ok := Lookup[string](c, "b")
-			if optB != "world" || !ok {
-				t.Errorf(`expected ("world", true) for option b lookup, got ("%s", %v)`, optB, ok)
+			if ive.InputInfo.ID != tt.expID {
+				t.Errorf("%s: expected input id '%s', got '%s'", tt.Case, tt.expID, ive.InputInfo.ID)
+			}
+			if ive.From != tt.expFrom {
+				t.Errorf("%s: expected from %+v, got %+v", tt.Case, tt.expFrom, ive.From)
+			}
+			if ive.RawValue != tt.expRaw {
+				t.Errorf("%s: expected raw value %q, got %q", tt.Case, tt.expRaw, ive.RawValue)
+			}
+			if !errors.Is(err, tt.expInner) {
+				t.Errorf("%s: expected error to wrap %v, got %v", tt.Case, tt.expInner, errors.Unwrap(err))
+			}
+		})
+	}
+}
+
+func TestSecretInvalidValueError(t *testing.T) {
+	errBadToken := errors.New("bad token")
+	in := NewCmd("app").
+		Opt(NewOpt("tok").Secret().WithParser(func(s string) (any, error) {
+			return nil, fmt.Errorf("%w %q", errBadToken, s)
+		}))
+
+	_, err := in.ParseThese("--tok", "hunter\t2")
+	const expMsg = "parsing option 'tok': invalid value"
+	if err == nil || err.Error() != expMsg {
+		t.Fatalf("expected error %q, got %v", expMsg, err)
+	}
+	if !errors.Is(err, errBadToken) {
+		t.Errorf("expected the error to still match the parser's error")
+	}
+	for e := error(err); e != nil; e = errors.Unwrap(e) {
+		if strings.Contains(e.Error(), "hunter") {
+			t.Errorf("expected no error message to include the secret, got %q", e.Error())
+		}
+	}
+}
+
+func TestConfigPrinter(t *testing.T) {
+	t.Setenv("CFG_HOST", "example.com")
+	t.Setenv("CFG_TOKEN", "hunter2")
+	in := NewCmd("app").
+		Opt(DefaultPrintConfigOpt).
+		Opt(NewOpt("host").Env("CFG_HOST")).
+		Opt(NewIntOpt("port").Default("8080")).
+		Opt(NewOpt("token").Env("CFG_TOKEN").Secret()).
+		Opt(NewOpt("user").Required()).
+		Subcmd(NewCmd("run").
+			Opt(NewListOpt("tag", nil)).
+			Arg(NewArg("target").Required()))
+
+	for _, tt := range []struct {
+		Case string
+		args []string
+		exp  string
+	}{
+		{
+			Case: ttCase(),
+			args: []string{"--print-config", "--port=9090", "run", "--tag", "a,b"},
+			exp: "command   input     value         source\n" +
+				"app       --host    example.com   env var $CFG_HOST\n" +
+				"app       --port    9090          option --port\n" +
+				"app       --token   [redacted]    env var $CFG_TOKEN\n" +
+				"app       --user                  (not set)\n" +
+				"app run   --tag     a,b           option --tag\n" +
+				"app run   target                  (not set)\n",
+		}, {
+			Case: ttCase(),
+			args: []string{"--print-config=json", "run", "x"},
+			exp: `[
+  {
+    "command": "app",
+    "id": "host",
+    "name": "--host",
+    "value": "example.com",
+    "raw": "example.com",
+    "source": "env var $CFG_HOST",
+    "from": {
+      "env": "CFG_HOST"
+    }
+  },
+  {
+    "command": "app",
+    "id": "port",
+    "name": "--port",
+    "value": "8080",
+    "raw": "8080",
+    "source": "default value",
+    "from": {
+      "default": true
+    }
+  },
+  {
+    "command": "app",
+    "id": "token",
+    "name": "--token",
+    "value": "[redacted]",
+    "raw": "[redacted]",
+    "source": "env var $CFG_TOKEN",
+    "from": {
+      "env": "CFG_TOKEN"
+    }
+  },
+  {
+    "command": "app",
+    "id": "user",
+    "name": "--user",
+    "value": "",
+    "raw": "",
+    "source": ""
+  },
+  {
+    "command": "app run",
+    "id": "tag",
+    "name": "--tag",
+    "value": "",
+    "raw": "",
+    "source": ""
+  },
+  {
+    "command": "app run",
+    "id": "target",
+    "name": "target",
+    "value": "x",
+    "raw": "x",
+    "source": "positional argument #1",
+    "from": {
+      "arg": 1
+    }
+  }
+]
+`,
+		},
+	} {
+		t.Run(tt.Case, func(t *testing.T) {
+			_, err := in.ParseThese(tt.args...)
+			var hvr HelpOrVersionRequested
+			if !errors.As(err, &hvr) {
+				t.Fatalf("expected a config printout, got %v", err)
+			}
+			if hvr.Msg != tt.exp {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.exp, hvr.Msg)
+			}
+		})
+	}
+
+	// Without the config option, the missing inputs are still an error.
+	_, err := in.ParseThese("run", "x")
+	if !errors.As(err, new(MissingOptionsError)) {
+		t.Errorf("expected a missing options error, got %v", err)
+	}
+
+	// A missing subcommand doesn't stop the printout, and every value of a list or map
+	// is formatted.
+	in = NewCmd("app").
+		Opt(DefaultPrintConfigOpt).
+		Opt(NewByteSizeOpt("limit").WithSeparator(",")).
+		Opt(NewMapOpt("quota", ParseByteSize).WithFormatter(FormatByteSize)).
+		Opt(NewByteSizeOpt("max")).
+		Subcmd(NewCmd("run"))
+	_, err = in.ParseThese("--print-config", "--limit", "1024,2048", "--quota", "a=1MiB", "--max", "1MiB")
+	var hvr HelpOrVersionRequested
+	if !errors.As(err, &hvr) {
+		t.Fatalf("expected a config printout, got %v", err)
+	}
+	exp := "command   input     value       source\n" +
+		"app       --limit   1KiB,2KiB   option --limit\n" +
+		"app       --quota   a=1MiB      option --quota\n" +
+		"app       --max     1MiB        option --max\n"
+	if hvr.Msg != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, hvr.Msg)
+	}
+	if _, err := in.ParseThese(); !errors.Is(err, ErrNoSubcmd) {
+		t.Errorf("expected a missing subcommand error without the config option, got %v", err)
+	}
+}
+
+func TestPrompting(t *testing.T) {
+	var echoCalls []bool
+	newPrompter := func(in string, out *strings.Builder) *Prompter {
+		echoCalls = nil
+		return &Prompter{
+			In:  strings.NewReader(in),
+			Out: out,
+			SetEcho: func(on bool) error {
+				echoCalls = append(echoCalls, on)
+				return nil
+			},
+		}
+	}
+
+	in := NewCmd("pr").
+		Opt(NewOpt("user").Help("Username").Required()).
+		Opt(NewOpt("token").Help("API token").Required().Secret()).
+		Opt(NewIntOpt("n")).
+		Arg(NewArg("count").WithParser(ParseInt).Required())
+
+	// everything missing gets prompted for in order
+	{
+		var out strings.Builder
+		pin := in.PromptMissing(newPrompter("alice\r\ns3cr3t\n42\n", &out))
+		c, err := pin.ParseThese("-n", "3")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		cmpParsed(t, ttCase(), &Command{
+			Inputs: []Input{
+				{ID: "n", From: ParsedFrom{Opt: "n"}, RawValue: "3", Value: 3},
+				{ID: "user", From: ParsedFrom{Prompt: true}, RawValue: "alice", Value: "alice"},
+				{ID: "token", From: ParsedFrom{Prompt: true}, RawValue: "s3cr3t", Value: "s3cr3t", IsSecret: true},
+				{ID: "count", From: ParsedFrom{Prompt: true}, RawValue: "42", Value: 42},
+			},
+		}, c)
+		if exp := "Username: API token: \ncount: "; out.String() != exp {
+			t.Errorf("expected prompt output %q, got %q", exp, out.String())
+		}
+		if !slices.Equal(echoCalls, []bool{false, true}) {
+			t.Errorf("expected echo to be turned off then on for the secret, got %v", echoCalls)
+		}
+	}
+
+	// running out of input leaves the rest missing
+	{
+		var out strings.Builder
+		pin := in.PromptMissing(newPrompter("alice\n", &out))
+		_, err := pin.ParseThese("5")
+		var moe MissingOptionsError
+		if !errors.As(err, &moe) || !slices.Equal(moe.Names, []string{"--token"}) {
+			t.Errorf("expected missing option --token, got %v", err)
+		}
+	}
+
+	// prompted values still go through the value parser
+	{
+		var out strings.Builder
+		pin := in.PromptMissing(newPrompter("alice\ntok\nabc\n", &out))
+		_, err := pin.ParseThese()
+		if exp := "parsing entered value for count: invalid syntax"; err == nil || err.Error() != exp {
+			t.Errorf("expected error %q, got %v", exp, err)
+		}
+	}
+
+	// nothing is prompted for when not interactive
+	{
+		var out strings.Builder
+		pr := newPrompter("alice\ntok\n1\n", &out)
+		pr.IsInteractive = func() bool { return false }
+		pin := in.PromptMissing(pr)
+		_, err := pin.ParseThese()
+		if !errors.As(err, new(MissingOptionsError)) || out.Len() != 0 {
+			t.Errorf("expected a missing options error and no prompts, got %v and %q", err, out.String())
+		}
+	}
+
+	// subcommands inherit the prompter but don't prompt when help is requested
+	{
+		var out strings.Builder
+		sc := NewCmd("root").
+			PromptMissing(newPrompter("R\nS\n", &out)).
+			Opt(NewOpt("r").Required()).
+			Subcmd(NewCmd("sc").Opt(NewOpt("s").Required()))
+
+		_, err := sc.ParseThese("sc", "-h")
+		if !errors.As(err, new(HelpOrVersionRequested)) || out.Len() != 0 {
+			t.Errorf("expected help and no prompts, got %v and %q", err, out.String())
+		}
+
+		c, err := sc.ParseThese("sc")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !hasOpt(c, "r") || !hasOpt(c.Subcmd, "s") {
+			t.Errorf("expected both missing options to be prompted for")
+		}
+	}
+}
+
+func TestTermPrompterWithoutStty(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	if pr := NewTermPrompter(); pr.SetEcho != nil {
+		t.Error("expected no SetEcho when stty can't be found")
+	}
+}
+
+func TestInputString(t *testing.T) {
+	for _, tt := range []struct {
+		Case string
+		in   Input
+		exp  string
+	}{
+		{
+			Case: ttCase(),
+			in:   Input{ID: "n", From: ParsedFrom{Opt: "n"}, RawValue: "3", Value: 3},
+			exp:  "n=3 (option -n)",
+		}, {
+			Case: ttCase(),
+			in:   Input{ID: "name", From: ParsedFrom{Env: "NAME"}, RawValue: "x", Value: "x"},
+			exp:  "name=x (env var $NAME)",
+		}, {
+			Case: ttCase(),
+			in:   Input{ID: "tok", From: ParsedFrom{Opt: "token"}, RawValue: "abc", Value: "abc", IsSecret: true},
+			exp:  "tok=[redacted] (option --token)",
+		}, {
+			Case: ttCase(),
+			in:   Input{ID: "arg1", From: ParsedFrom{Arg: 2}, RawValue: "z", Value: "z"},
+			exp:  "arg1=z (positional argument #2)",
+		}, {
+			Case: ttCase(),
+			in:   Input{ID: "d", From: ParsedFrom{Default: true}, RawValue: "1", Value: "1", IsSecret: true},
+			exp:  "d=[redacted] (default value)",
+		},
+	} {
+		if got := tt.in.String(); got != tt.exp {
+			t.Errorf("%s: expected %q, got %q", tt.Case, tt.exp, got)
+		}
+	}
+}
+
+func TestStdinValues(t *testing.T) {
+	newCmd := func(stdin string) CommandInfo {
+		return NewCmd("stdin").
+			WithStdin(strings.NewReader(stdin)).
+			Opt(NewOpt("password").AllowStdin().Secret()).
+			Opt(NewOpt("token").AllowStdin()).
+			Opt(NewOpt("name")).
+			Subcmd(NewCmd("count").
+				Arg(NewArg("n").WithParser(ParseInt).AllowStdin()))
+	}
+
+	for _, tt := range []struct {
+		Case     string
+		stdin    string
+		args     []string
+		expected Command
+		expErr   string
+	}{
+		{
+			Case:  ttCase(),
+			stdin: "hunter2\n",
+			args:  []string{"--password", "-", "--name", "-", "count", "3"},
+			expected: Command{
+				Inputs: []Input{
+					{ID: "password", From: ParsedFrom{Opt: "password"}, RawValue: "hunter2", Value: "hunter2", IsSecret: true},
+					{ID: "name", From: ParsedFrom{Opt: "name"}, RawValue: "-", Value: "-"},
+				},
+				Subcmd: &Command{
+					Name:   "count",
+					Inputs: []Input{{ID: "n", From: ParsedFrom{Arg: 1}, RawValue: "3", Value: 3}},
+				},
+			},
+		}, {
+			Case:   ttCase(),
+			stdin:  "42\n\n",
+			args:   []string{"--token=abc", "count", "-"},
+			expErr: "parsing positional argument #1 '42\n': invalid syntax",
+		}, {
+			Case:  ttCase(),
+			stdin: "42\n",
+			args:  []string{"--token=abc", "count", "-"},
+			expected: Command{
+				Inputs: []Input{{ID: "token", From: ParsedFrom{Opt: "token"}, RawValue: "abc", Value: "abc"}},
+				Subcmd: &Command{
+					Name:   "count",
+					Inputs: []Input{{ID: "n", From: ParsedFrom{Arg: 1}, RawValue: "42", Value: 42}},
+				},
+			},
+		}, {
+			Case:  ttCase(),
+			stdin: "42\r\n",
+			args:  []string{"--token=abc", "count", "-"},
+			expected: Command{
+				Inputs: []Input{{ID: "token", From: ParsedFrom{Opt: "token"}, RawValue: "abc", Value: "abc"}},
+				Subcmd: &Command{
+					Name:   "count",
+					Inputs: []Input{{ID: "n", From: ParsedFrom{Arg: 1}, RawValue: "42", Value: 42}},
+				},
+			},
+		}, {
+			Case:   ttCase(),
+			stdin:  "42\r\n\r\n",
+			args:   []string{"--token=abc", "count", "-"},
+			expErr: "parsing positional argument #1 '42\r\n': invalid syntax",
+		}, {
+			Case:   ttCase(),
+			stdin:  "s3cr3t",
+			args:   []string{"--password", "-", "--token", "-"},
+			expErr: "parsing option 'token': stdin was already read for --password",
+		}, {
+			Case:   ttCase(),
+			stdin:  "s3cr3t",
+			args:   []string{"--token=-", "--token=-"},
+			expErr: "parsing option 'token': stdin was already read for --token",
+		},
+	} {
+		in := newCmd(tt.stdin)
+		c, err := in.ParseThese(tt.args...)
+		if tt.expErr != "" {
+			if err == nil || err.Error() != tt.expErr {
+				t.Errorf("%s: expected error %q, got %v", tt.Case, tt.expErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.Case, err)
+			continue
+		}
+		cmpParsed(t, tt.Case, &tt.expected, c)
+	}
+
+	// A new parse can read stdin again.
+	in := NewCmd("stdin").Opt(NewOpt("key").AllowStdin())
+	for _, v := range []string{"a", "b"} {
+		in.Stdin = strings.NewReader(v)
+		c, err := in.ParseThese("--key", "-")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := Get[string](c, "key"); got != v {
+			t.Errorf("expected %q, got %q", v, got)
+		}
+	}
+}
+
+func TestFlexTimeParser(t *testing.T) {
+	loc := time.FixedZone("EST", -5*60*60)
+	now := time.Date(2025, 3, 1, 2, 30, 0, 0, time.UTC) // Feb 28 at 21:30 in EST
+	vp := NewFlexTimeParser(FlexTimeConfig{
+		Location: loc,
+		Now:      func() time.Time { return now },
+	})
+
+	for _, tt := range []struct {
+		Case   string
+		in     string
+		exp    time.Time
+		expErr string
+	}{
+		{Case: ttCase(), in: "now", exp: now.In(loc)},
+		{Case: ttCase(), in: "today", exp: time.Date(2025, 2, 28, 0, 0, 0, 0, loc)},
+		{Case: ttCase(), in: "yesterday", exp: time.Date(2025, 2, 27, 0, 0, 0, 0, loc)},
+		{Case: ttCase(), in: "tomorrow", exp: time.Date(2025, 3, 1, 0, 0, 0, 0, loc)},
+		{Case: ttCase(), in: "2024-06-01T12:00:00Z", exp: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)},
+		{Case: ttCase(), in: "2024-06-01T12:00:00.5+02:00", exp: time.Date(2024, 6, 1, 12, 0, 0, 5e8, time.FixedZone("", 2*60*60))},
+		{Case: ttCase(), in: "2024-06-01T12:00:00", exp: time.Date(2024, 6, 1, 12, 0, 0, 0, loc)},
+		{Case: ttCase(), in: "2024-06-01 12:00:00", exp: time.Date(2024, 6, 1, 12, 0, 0, 0, loc)},
+		{Case: ttCase(), in: "2024-06-01 12:00", exp: time.Date(2024, 6, 1, 12, 0, 0, 0, loc)},
+		{Case: ttCase(), in: "2024-06-01", exp: time.Date(2024, 6, 1, 0, 0, 0, 0, loc)},
+		{Case: ttCase(), in: "1700000000", exp: time.Date(2023, 11, 14, 17, 13, 20, 0, loc)},
+		{Case: ttCase(), in: "1700000000123", exp: time.Date(2023, 11, 14, 17, 13, 20, 123e6, loc)},
+		{Case: ttCase(), in: "@86400", exp: time.Date(1970, 1, 1, 19, 0, 0, 0, loc)},
+		{Case: ttCase(), in: "@0", exp: time.Date(1969, 12, 31, 19, 0, 0, 0, loc)},
+		{Case: ttCase(), in: "-2h", exp: now.In(loc).Add(-2 * time.Hour)},
+		{Case: ttCase(), in: "+1h30m", exp: now.In(loc).Add(90 * time.Minute)},
+		{Case: ttCase(), in: "-7d", exp: now.In(loc).Add(-7 * 24 * time.Hour)},
+		{Case: ttCase(), in: "2h", expErr: `unrecognized time (expected a time such as "2006-01-02T15:04:05Z07:00", a Unix timestamp such as "@1700000000", or a relative time such as "-2h" or "yesterday")`},
+		{Case: ttCase(), in: "-", expErr: `unrecognized time (expected a time such as "2006-01-02T15:04:05Z07:00", a Unix timestamp such as "@1700000000", or a relative time such as "-2h" or "yesterday")`},
+		{Case: ttCase(), in: "2024-13-01", expErr: `unrecognized time (expected a time such as "2006-01-02T15:04:05Z07:00", a Unix timestamp such as "@1700000000", or a relative time such as "-2h" or "yesterday")`},
+		{Case: ttCase(), in: "20240601", expErr: `unrecognized time (expected a time such as "2006-01-02T15:04:05Z07:00", a Unix timestamp such as "@1700000000", or a relative time such as "-2h" or "yesterday")`},
+		{Case: ttCase(), in: "@", expErr: `unrecognized time (expected a time such as "2006-01-02T15:04:05Z07:00", a Unix timestamp such as "@1700000000", or a relative time such as "-2h" or "yesterday")`},
+		{Case: ttCase(), in: "@-5", expErr: `unrecognized time (expected a time such as "2006-01-02T15:04:05Z07:00", a Unix timestamp such as "@1700000000", or a relative time such as "-2h" or "yesterday")`},
+	} {
+		v, err := vp(tt.in)
+		if tt.expErr != "" {
+			if err == nil || err.Error() != tt.expErr {
+				t.Errorf("%s: expected error %q, got %v", tt.Case, tt.expErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.Case, err)
+			continue
+		}
+		got := v.(time.Time)
+		if !got.Equal(tt.exp) || got.Location().String() != tt.exp.Location().String() {
+			t.Errorf("%s: expected %v, got %v", tt.Case, tt.exp, got)
+		}
+	}
+
+	// custom layouts
+	vp = NewFlexTimeParser(FlexTimeConfig{Layouts: []string{"02/01/2006"}, Location: time.UTC})
+	if v, err := vp("25/12/2024"); err != nil || !v.(time.Time).Equal(time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected Christmas 2024, got %v (%v)", v, err)
+	}
+	if _, err := vp("2024-12-25"); err == nil {
+		t.Errorf("expected an error for a layout that wasn't configured")
+	}
+}
+
+func TestFormatByteSize(t *testing.T) {
+	for _, tt := range []struct {
+		in  uint64
+		exp string
+	}{
+		{0, "0B"},
+		{999, "999B"},
+		{1 << 20, "1MiB"},
+		{1500000000, "1500MB"},
+		{1024000, "1000KiB"},
+		{1024000000, "1024MB"},
+		{5 << 40, "5TiB"},
+		{math.MaxUint64, "18446744073709551615B"},
+	} {
+		got := FormatByteSize(tt.in)
+		if got != tt.exp {
+			t.Errorf("%d: expected %q, got %q", tt.in, tt.exp, got)
+		}
+		// Every formatted size should parse back to the same value.
+		if v, err := ParseByteSize(got); err != nil || v != tt.in {
+			t.Errorf("%d: round trip of %q gave %v (%v)", tt.in, got, v, err)
+		}
+	}
+}
+
+func TestFormatExtendedDuration(t *testing.T) {
+	for _, tt := range []struct {
+		in  time.Duration
+		exp string
+	}{
+		{0, "0s"},
+		{90 * time.Second, "1m30s"},
+		{12 * time.Hour, "12h"},
+		{90 * time.Minute, "1h30m"},
+		{1500 * time.Millisecond, "1.5s"},
+		{24 * time.Hour, "1d"},
+		{10 * 24 * time.Hour, "10d"},
+		{14 * 24 * time.Hour, "2w"},
+		{36*time.Hour + 30*time.Minute, "1d12h30m"},
+		{-36 * time.Hour, "-1d12h"},
+		{-30 * time.Minute, "-30m"},
+		{math.MinInt64, "-106751d23h47m16.854775808s"},
+	} {
+		got := FormatExtendedDuration(tt.in)
+		if got != tt.exp {
+			t.Errorf("%v: expected %q, got %q", int64(tt.in), tt.exp, got)
+		}
+		// Every formatted duration should parse back to the same value.
+		if v, err := ParseExtendedDuration(got); err != nil || v != tt.in {
+			t.Errorf("%v: round trip of %q gave %v (%v)", int64(tt.in), got, v, err)
+		}
+	}
+}
+
+func TestPathParsers(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(home, "file.txt")
+	if err := os.WriteFile(file, []byte("hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	roDir := filepath.Join(home, "ro")
+	if err := os.Mkdir(roDir, 0o555); err != nil {
+		t.Fatal(err)
+	}
+
+	in := NewCmd("paths").
+		Opt(NewOpt("path").WithParser(ParsePath)).
+		Opt(NewOpt("file").WithParser(ParseExistingFile)).
+		Opt(NewOpt("dir").WithParser(ParseExistingDir)).
+		Opt(NewOpt("new").WithParser(ParseNewPath)).
+		Opt(NewOpt("writable").WithParser(ParseWritablePath)).
+		Opt(NewOpt("input").WithParser(ParseInputFile)).
+		Opt(NewOpt("output").WithParser(ParseOutputFile))
+
+	for _, tt := range []struct {
+		Case   string
+		args   []string
+		exp    any
+		expErr string
+	}{
+		{Case: ttCase(), args: []string{"--path", "~"}, exp: home},
+		{Case: ttCase(), args: []string{"--path", "~/a/../b"}, exp: filepath.Join(home, "b")},
+		{Case: ttCase(), args: []string{"--path", "rel/x"}, exp: filepath.Join(wd, "rel", "x")},
+		{Case: ttCase(), args: []string{"--path", "~other/x"}, exp: filepath.Join(wd, "~other", "x")},
+		{Case: ttCase(), args: []string{"--path", ""}, expErr: "parsing option 'path': empty path"},
+		{Case: ttCase(), args: []string{"--file", "~/file.txt"}, exp: file},
+		{Case: ttCase(), args: []string{"--file", "~"}, expErr: "parsing option 'file': '" + home + "' is a directory"},
+		{Case: ttCase(), args: []string{"--file", "~/nope"}, expErr: "parsing option 'file': stat " + filepath.Join(home, "nope") + ": no such file or directory"},
+		{Case: ttCase(), args: []string{"--dir", "~"}, exp: home},
+		{Case: ttCase(), args: []string{"--dir", "~/file.txt"}, expErr: "parsing option 'dir': '" + file + "' is not a directory"},
+		{Case: ttCase(), args: []string{"--new", "~/new.txt"}, exp: filepath.Join(home, "new.txt")},
+		{Case: ttCase(), args: []string{"--new", "~/file.txt"}, expErr: "parsing option 'new': '" + file + "' already exists"},
+		{Case: ttCase(), args: []string{"--writable", "~/file.txt"}, exp: file},
+		{Case: ttCase(), args: []string{"--writable", "~/new.txt"}, exp: filepath.Join(home, "new.txt")},
+		{Case: ttCase(), args: []string{"--writable", "~"}, expErr: "parsing option 'writable': '" + home + "' is a directory"},
+		{Case: ttCase(), args: []string{"--writable", "~/nodir/x"}, expErr: "parsing option 'writable': stat " + filepath.Join(home, "nodir") + ": no such file or directory"},
+		{Case: ttCase(), args: []string{"--writable", "~/file.txt/x"}, expErr: "parsing option 'writable': stat " + filepath.Join(file, "x") + ": not a directory"},
+		{Case: ttCase(), args: []string{"--writable", "~/ro/x"}, expErr: "parsing option 'writable': write " + roDir + ": permission denied"},
+		{Case: ttCase(), args: []string{"--input", "~/file.txt"}, exp: InputFile{Path: file}},
+		{Case: ttCase(), args: []string{"--input", "~"}, expErr: "parsing option 'input': '" + home + "' is a directory"},
+		{Case: ttCase(), args: []string{"--input", "~/nope"}, expErr: "parsing option 'input': stat " + filepath.Join(home, "nope") + ": no such file or directory"},
+		{Case: ttCase(), args: []string{"--output", "-"}, exp: OutputFile{Path: "-"}},
+		{Case: ttCase(), args: []string{"--output", "~/out.txt"}, exp: OutputFile{Path: filepath.Join(home, "out.txt")}},
+		{Case: ttCase(), args: []string{"--output", "~/nodir/out.txt"}, expErr: "parsing option 'output': stat " + filepath.Join(home, "nodir") + ": no such file or directory"},
+	} {
+		c, err := in.ParseThese(tt.args...)
+		if tt.expErr != "" {
+			if err == nil || err.Error() != tt.expErr {
+				t.Errorf("%s: expected error %q, got %v", tt.Case, tt.expErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.Case, err)
+			continue
+		}
+		if got := c.Inputs[0].Value; got != tt.exp {
+			t.Errorf("%s: expected %v, got %v", tt.Case, tt.exp, got)
+		}
+	}
+
+	// Parsing must not create anything, even for values that get replaced.
+	for _, name := range []string{"new.txt", "out.txt"} {
+		if _, err := os.Stat(filepath.Join(home, name)); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected parsing to leave no %s behind, got %v", name, err)
+		}
+	}
+	if _, err := in.ParseThese("--output", "~/a.txt", "--output", "~/b.txt"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if entries, _ := os.ReadDir(home); len(entries) != 2 {
+		t.Errorf("expected parsing output files to create nothing, found %d entries", len(entries))
+	}
+
+	// opening files
+	c, err := in.ParseThese("--input", "~/file.txt", "--output", "~/out.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r, err := Get[InputFile](c, "input").Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if b, err := io.ReadAll(r); err != nil || string(b) != "hello\n" {
+		t.Errorf("expected to read %q from the input file, got %q (%v)", "hello\n", b, err)
+	}
+	w, err := Get[OutputFile](c, "output").Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, "bye\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(filepath.Join(home, "out.txt")); err != nil || string(b) != "bye\n" {
+		t.Errorf("expected %q in the output file, got %q (%v)", "bye\n", b, err)
+	}
+
+	// stdin
+	stdinCmd := in.WithStdin(strings.NewReader("piped\n")).
+		Opt(NewOpt("token").AllowStdin())
+	c, err = stdinCmd.ParseThese("--input", "-")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r, err = Get[InputFile](c, "input").Open(); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := io.ReadAll(r); err != nil || string(b) != "piped\n" {
+		t.Errorf("expected to read %q from stdin, got %q (%v)", "piped\n", b, err)
+	}
+	_, err = stdinCmd.ParseThese("--token", "-", "--input", "-")
+	if expErr := "parsing option 'input': stdin was already read for --token"; err == nil || err.Error() != expErr {
+		t.Errorf("expected error %q, got %v", expErr, err)
+	}
+	// An input file only claims stdin once it's known to be kept, so a replaced value
+	// doesn't keep anything else from reading it.
+	_, err = stdinCmd.ParseThese("--input", "-", "--token", "-")
+	if expErr := "parsing option 'input': stdin was already read for --token"; err == nil || err.Error() != expErr {
+		t.Errorf("expected error %q, got %v", expErr, err)
+	}
+	catCmd := NewCmd("cat").
+		WithStdin(strings.NewReader("piped\n")).
+		Opt(NewOpt("in").WithParser(ParseInputFile).Default("-")).
+		Arg(NewArg("tok").AllowStdin())
+	c, err = catCmd.ParseThese("--in", "~/file.txt", "-")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := Get[string](c, "tok"); got != "piped" {
+		t.Errorf("expected the token to be read from stdin, got %q", got)
+	}
+	if got := Get[InputFile](c, "in").Path; got != file {
+		t.Errorf("expected the input file %q, got %q", file, got)
+	}
+}
+
+func TestBinding(t *testing.T) {
+	type config struct {
+		Verbose bool
+		Port    int
+		Host    string
+		Tags    []string
+		Labels  map[string]string
+		Name    string
+		Count   uint
+	}
+
+	var cfg config
+	newCmd := func() CommandInfo {
+		cfg = config{Host: "localhost", Tags: []string{"x"}}
+		return NewCmd("bind").
+			Opt(NewBoolOpt("verbose").Short('v').Bind(BindTo(&cfg.Verbose))).
+			Opt(NewIntOpt("port").Default("8080").Bind(BindTo(&cfg.Port))).
+			Opt(NewOpt("host").Bind(BindTo(&cfg.Host))).
+			Opt(NewOpt("tag").Bind(BindAll(&cfg.Tags))).
+			Opt(NewMapOpt("label", nil).Bind(BindMap(&cfg.Labels))).
+			Subcmd(NewCmd("run").
+				Arg(NewArg("name").Required().Bind(BindTo(&cfg.Name))).
+				Arg(NewArg("count").WithParser(ParseUint).Bind(BindTo(&cfg.Count)))).
+			Subcmd(NewCmd("other").
+				Arg(NewArg("name").Bind(BindTo(&cfg.Name))))
+	}
+
+	for _, tt := range []struct {
+		Case string
+		args []string
+		exp  config
+	}{
+		{
+			Case: ttCase(),
+			args: []string{"run", "job"},
+			exp:  config{Port: 8080, Host: "localhost", Tags: []string{"x"}, Name: "job"},
+		}, {
+			Case: ttCase(),
+			args: []string{"-v", "--port", "9", "--host", "example.com", "--tag", "a", "--tag", "b", "--label", "k=v", "--label", "k=w", "run", "job", "3"},
+			exp: config{
+				Verbose: true, Port: 9, Host: "example.com", Tags: []string{"a", "b"},
+				Labels: map[string]string{"k": "w"}, Name: "job", Count: 3,
+			},
+		}, {
+			// Inputs of subcommands that weren't parsed are never bound.
+			Case: ttCase(),
+			args: []string{"other"},
+			exp:  config{Port: 8080, Host: "localhost", Tags: []string{"x"}},
+		},
+	} {
+		in := newCmd()
+		if _, err := in.ParseThese(tt.args...); err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.Case, err)
+			continue
+		}
+		if !reflect.DeepEqual(cfg, tt.exp) {
+			t.Errorf("%s: expected %+v, got %+v", tt.Case, tt.exp, cfg)
+		}
+	}
+
+	// Nothing is bound if parsing fails.
+	in := newCmd()
+	if _, err := in.ParseThese("--port", "1", "run"); err == nil {
+		t.Fatal("expected an error")
+	}
+	if cfg.Port != 0 {
+		t.Errorf("expected nothing to be bound after a failed parse, got port %d", cfg.Port)
+	}
+
+	// mismatched types
+	var port string
+	in = NewCmd("bind").Opt(NewIntOpt("port").Bind(BindTo(&port)))
+	_, err := in.ParseThese("--port", "1")
+	var be BindError
+	if !errors.As(err, &be) || be.InputInfo.ID != "port" {
+		t.Fatalf("expected a BindError for 'port', got %v", err)
+	}
+	if exp := "bind: binding '--port': input 'port' has a value of type int, not string"; err.Error() != exp {
+		t.Errorf("expected error %q, got %q", exp, err.Error())
+	}
+}
+
+func TestTypedHandles(t *testing.T) {
+	port := TypedOpt("port", strconv.Atoi).Short('p').Default("8080").Env("PORT")
+	verbose := TypedBoolOpt("verbose").Short('v')
+	tags := TypedStringOpt("tag").Help("A tag.")
+	level := TypedOpt("level", func(s string) (slog.Level, error) {
+		var l slog.Level
+		return l, l.UnmarshalText([]byte(s))
+	}).With(func(in InputInfo) InputInfo { return in.WithValueName("lvl").WithParser(ParseInt) })
+	name := TypedStringArg("name").Required()
+	count := TypedArg("count", strconv.Atoi)
+	size := TypedOpt("size", TypedParser[uint64](ParseByteSize))
+	wrong := TypedOpt("wrong", TypedParser[int](ParseUint))
+	extras := TypedArg("extras", strconv.Atoi).With(func(in InputInfo) InputInfo { return in.WithSeparator(",") })
+
+	var boundName string
+	in := NewCmd("typed").
+		Opt(port.Info).
+		Opt(verbose.Info).
+		Opt(tags.Info).
+		Opt(level.Info).
+		Opt(size.Info).
+		Opt(wrong.Info).
+		Arg(name.BindTo(&boundName).Info).
+		Arg(count.Info).
+		Arg(extras.Info)
+
+	if port.Info.NameShort != 'p' || port.Info.EnvVar != "PORT" || level.Info.ValueName != "lvl" {
+		t.Fatalf("expected the builder methods to be applied, got %+v and %+v", port.Info, level.Info)
+	}
+
+	c, err := in.ParseThese("-v", "--tag", "a", "--tag", "b", "--level", "warn", "alice")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := port.Get(c); got != 8080 {
+		t.Errorf("expected port 8080, got %d", got)
+	}
+	if got := verbose.Get(c); !got {
+		t.Errorf("expected verbose to be true")
+	}
+	if got := tags.GetAll(c); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("expected tags [a b], got %v", got)
+	}
+	if got, ok := level.Lookup(c); !ok || got != slog.LevelWarn {
+		t.Errorf("expected level WARN, got %v (%v)", got, ok)
+	}
+	if got := name.Get(c); got != "alice" || boundName != "alice" {
+		t.Errorf("expected name alice, got %q (bound %q)", got, boundName)
+	}
+	if _, ok := count.Lookup(c); ok {
+		t.Errorf("expected no count")
+	}
+	if got := count.GetOr(c, 1); got != 1 {
+		t.Errorf("expected the fallback count, got %d", got)
+	}
+
+	c, err = in.ParseThese("--size", "2KiB", "alice", "1", "2,3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := extras.GetAll(c); !slices.Equal(got, []int{2, 3}) {
+		t.Errorf("expected extras [2 3], got %v", got)
+	}
+	if got := size.Get(c); got != 2048 {
+		t.Errorf("expected size 2048, got %d", got)
+	}
+	_, err = in.ParseThese("--size", "2XB", "alice")
+	if exp := "parsing option 'size': unknown byte size unit 'XB'"; err == nil || err.Error() != exp {
+		t.Errorf("expected error %q, got %v", exp, err)
+	}
+	_, err = in.ParseThese("--wrong", "1", "alice")
+	if exp := "parsing option 'wrong': value parser returned a value of type uint, not int"; err == nil || err.Error() != exp {
+		t.Errorf("expected error %q, got %v", exp, err)
+	}
+
+	_, err = in.ParseThese("-p", "x", "alice")
+	if exp := `parsing option 'p': strconv.Atoi: parsing "x": invalid syntax`; err == nil || err.Error() != exp {
+		t.Errorf("expected error %q, got %v", exp, err)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("expected a panic for a nil parse function")
+		}
+	}()
+	TypedOpt[int]("n", nil)
+}
+
+func TestTryLookups(t *testing.T) {
+	in := NewCmd("try").
+		Opt(NewIntOpt("n")).
+		Opt(NewOpt("s")).
+		Opt(NewMapOpt("m", ParseInt)).
+		Opt(NewOpt("e").WithParser(func(s string) (any, error) { return errors.New(s), nil }))
+	c, err := in.ParseThese("-n", "1", "-n", "2", "-s", "x", "-m", "a=1", "-m", "b=2", "-e", "oops")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if v, err := TryGet[int](c, "n"); v != 2 || err != nil {
+		t.Errorf("expected 2, got %v (%v)", v, err)
+	}
+	if v, err := TryGet[error](c, "e"); v == nil || v.Error() != "oops" || err != nil {
+		t.Errorf("expected the error value, got %v (%v)", v, err)
+	}
+	if v, ok, err := TryLookup[string](c, "nope"); v != "" || ok || err != nil {
+		t.Errorf("expected nothing, got %q, %v, %v", v, ok, err)
+	}
+	if v, err := TryGetOr(c, "nope", 7); v != 7 || err != nil {
+		t.Errorf("expected the fallback, got %v (%v)", v, err)
+	}
+	if v, err := TryGetAll[int](c, "n"); !slices.Equal(v, []int{1, 2}) || err != nil {
+		t.Errorf("expected [1 2], got %v (%v)", v, err)
+	}
+	if v, err := TryGetMap[int](c, "m"); !reflect.DeepEqual(v, map[string]int{"a": 1, "b": 2}) || err != nil {
+		t.Errorf("expected map[a:1 b:2], got %v (%v)", v, err)
+	}
+
+	var nfe NotFoundError
+	if _, err := TryGet[int](c, "nope"); !errors.As(err, &nfe) || nfe.ID != "nope" {
+		t.Errorf("expected a NotFoundError, got %v", err)
+	} else if exp := "no parsed input value for id 'nope'"; err.Error() != exp {
+		t.Errorf("expected error %q, got %q", exp, err.Error())
+	}
+
+	for _, tt := range []struct {
+		Case string
+		err  error
+		exp  TypeMismatchError
+	}{
+		{Case: ttCase(), err: func() error { _, err := TryGet[string](c, "n"); return err }(), exp: TypeMismatchError{ID: "n", Expected: "string", Actual: "int"}},
+		{Case: ttCase(), err: func() error { _, _, err := TryLookup[image.Point](c, "s"); return err }(), exp: TypeMismatchError{ID: "s", Expected: "image.Point", Actual: "string"}},
+		{Case: ttCase(), err: func() error { _, err := TryGetOr[fmt.Stringer](c, "n", nil); return err }(), exp: TypeMismatchError{ID: "n", Expected: "fmt.Stringer", Actual: "int"}},
+		{Case: ttCase(), err: func() error { _, err := TryGetAll[*int](c, "n"); return err }(), exp: TypeMismatchError{ID: "n", Expected: "*int", Actual: "int"}},
+		{Case: ttCase(), err: func() error { _, err := TryGetMap[string](c, "m"); return err }(), exp: TypeMismatchError{ID: "m", Expected: "string", Actual: "int"}},
+		{Case: ttCase(), err: func() error { _, err := TryGetMap[string](c, "s"); return err }(), exp: TypeMismatchError{ID: "s", Expected: "cli.KeyValue", Actual: "string"}},
+	} {
+		var tme TypeMismatchError
+		if !errors.As(tt.err, &tme) || tme != tt.exp {
+			t.Errorf("%s: expected %+v, got %v", tt.Case, tt.exp, tt.err)
+		}
+	}
+	if _, err := TryGet[string](c, "n"); err.Error() != "input 'n' has a value of type int, not string" {
+		t.Errorf("unexpected error message %q", err.Error())
+	}
+}
+
+func TestCommandChain(t *testing.T) {
+	in := NewCmd("root").
+		Opt(NewBoolOpt("verbose").Short('v')).
+		Opt(NewOpt("name").Default("root-name")).
+		Subcmd(NewCmd("mid").
+			Opt(NewOpt("name")).
+			Opt(NewIntOpt("level")).
+			Subcmd(NewCmd("leaf").
+				Opt(NewIntOpt("n"))))
+
+	c, err := in.ParseThese("-v", "mid", "--level", "3", "leaf", "-n", "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	leaf := c.Subcmd.Subcmd
+
+	if leaf.Root() != c || c.Root() != c {
+		t.Errorf("expected the root of every command to be the root command")
+	}
+	var names []string
+	for cmd := range leaf.Chain() {
+		names = append(names, cmd.Name)
+	}
+	if exp := []string{"", "mid", "leaf"}; !slices.Equal(names, exp) {
+		t.Errorf("expected chain %q, got %q", exp, names)
+	}
+	for cmd := range c.Subcmd.Chain() {
+		if cmd.Name == "mid" {
+			break
+		}
+		if cmd != c {
+			t.Errorf("expected the chain to start at the root")
+		}
+	}
+
+	if !GetInherited[bool](leaf, "verbose") {
+		t.Errorf("expected to find the root's verbose option from the leaf")
+	}
+	if got := GetInherited[int](leaf, "level"); got != 3 {
+		t.Errorf("expected level 3, got %d", got)
+	}
+	if got := GetInherited[int](leaf, "n"); got != 1 {
+		t.Errorf("expected n 1, got %d", got)
+	}
+	// The nearest command with a value wins, and "mid" has no value for "name".
+	if got := GetInherited[string](leaf, "name"); got != "root-name" {
+		t.Errorf("expected the root's name, got %q", got)
+	}
+	if _, ok := LookupInherited[int](c, "level"); ok {
+		t.Errorf("expected parent commands not to see subcommand values")
+	}
+	if got := GetOrInherited(leaf, "nope", "x"); got != "x" {
+		t.Errorf("expected the fallback, got %q", got)
+	}
+	if got := GetAllInherited[int](leaf, "level"); !slices.Equal(got, []int{3}) {
+		t.Errorf("expected levels [3], got %v", got)
+	}
+	if got := GetAllInherited[int](leaf, "nope"); got == nil || len(got) != 0 {
+		t.Errorf("expected an empty slice, got %#v", got)
+	}
+	if v, ok, err := TryLookupInherited[int](leaf, "level"); v != 3 || !ok || err != nil {
+		t.Errorf("expected level 3, got %v (%v, %v)", v, ok, err)
+	}
+	if _, ok, err := TryLookupInherited[string](leaf, "verbose"); !ok || err == nil {
+		t.Errorf("expected a type mismatch for the root's verbose option, got %v (%v)", ok, err)
+	}
+	if v, ok, err := TryLookupInherited[int](leaf, "nope"); v != 0 || ok || err != nil {
+		t.Errorf("expected nothing, got %v (%v, %v)", v, ok, err)
+	}
+
+	// Parent must not make the parsed structure cyclic for encoding.
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling a parsed command: %v", err)
+	}
+	if strings.Contains(string(b), `"Parent"`) {
+		t.Errorf("expected no Parent field in %s", b)
+	}
+
+	c, err = in.ParseThese("mid", "--name", "mid-name", "leaf")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := GetInherited[string](c.Subcmd.Subcmd, "name"); got != "mid-name" {
+		t.Errorf("expected the mid command's name, got %q", got)
+	}
+}
+
+// newLargeCmd returns a command with n options (26 of them with short names), n
+// subcommands, and a map option with unique keys.
+func newLargeCmd(n int) CommandInfo {
+	in := NewCmd("large")
+	for i := range n {
+		o := NewIntOpt("opt-" + strconv.Itoa(i))
+		if i < 26 {
+			o = o.Short(byte('A' + i))
+		}
+		if i%10 == 0 {
+			o = o.Default(strconv.Itoa(i))
+		}
+		in = in.Opt(o)
+	}
+	in = in.Opt(NewListOpt("list", nil)).
+		Opt(NewMapOpt("labels", nil).UniqueKeys())
+	for i := range n {
+		in = in.Subcmd(NewCmd("sub-" + strconv.Itoa(i)).Opt(NewIntOpt("x")))
+	}
+	return in
+}
+
+// largeArgs returns n values spread across the options of a command from newLargeCmd.
+func largeArgs(nOpts, n int) []string {
+	args := make([]string, 0, n*2+4)
+	for i := range n {
+		switch i % 4 {
+		case 0:
+			args = append(args, "--opt-"+strconv.Itoa(i%nOpts), strconv.Itoa(i))
+		case 1:
+			args = append(args, "-"+string(byte('A'+i%26)), strconv.Itoa(i))
+		case 2:
+			args = append(args, "--list", "v"+strconv.Itoa(i))
+		case 3:
+			args = append(args, "--labels", "k"+strconv.Itoa(i)+"=v")
+		}
+	}
+	return append(args, "sub-"+strconv.Itoa(nOpts-1), "-x", "1")
+}
+
+func TestLargeCommandIndex(t *testing.T) {
+	in := newLargeCmd(300)
+	in.prepareAndValidate()
+	args := largeArgs(300, 3000)
+
+	c, err := in.ParseThese(args...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.validIndex() == nil {
+		t.Fatal("expected a large command to be indexed")
+	}
+	if c.Subcmd.index != nil {
+		t.Error("expected a small command not to be indexed")
+	}
+
+	// Every indexed lookup must match a plain scan of the inputs.
+	check := func(c *Command) {
+		t.Helper()
+		ids := []string{"list", "labels", "nope"}
+		for i := range 300 {
+			ids = append(ids, "opt-"+strconv.Itoa(i))
+		}
+		for _, id := range ids {
+			var want []Input
+			for _, pi := range c.Inputs {
+				if pi.ID == id {
+					want = append(want, pi)
+				}
+			}
+			var got []Input
+			for i := c.firstPos(id); i != -1; i = c.nextPos(i) {
+				got = append(got, c.Inputs[i])
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("%s: expected %d values, got %d", id, len(want), len(got))
+			}
+			last, ok := lastInput(c, id)
+			if ok != (len(want) > 0) || ok && !reflect.DeepEqual(last, want[len(want)-1]) {
+				t.Fatalf("%s: expected the last value %v, got %v", id, want, last)
+			}
+		}
+	}
+	check(c)
+	if got := len(GetAll[string](c, "list")); got != 750 {
+		t.Errorf("expected 750 list values, got %d", got)
+	}
+	if got := len(GetMap[string](c, "labels")); got != 750 {
+		t.Errorf("expected 750 labels, got %d", got)
+	}
+
+	// Appending to the inputs makes the index stale, so lookups go back to scanning.
+	c.Inputs = append(c.Inputs, Input{ID: "opt-1", Value: -1})
+	if c.validIndex() != nil {
+		t.Fatal("expected the index to be stale after appending to the inputs")
+	}
+	if got := Get[int](c, "opt-1"); got != -1 {
+		t.Errorf("expected the appended value, got %d", got)
+	}
+	check(c)
+
+	// Value policies that delete inputs keep the index up to date.
+	t.Setenv("LARGE_OPT_0", "1,2")
+	in = newLargeCmd(300)
+	in.Opts[0] = in.Opts[0].Env("LARGE_OPT_0").WithSeparator(",").WithValuePolicy(ReplaceBySource)
+	in.prepareAndValidate()
+	c, err = in.ParseThese(append([]string{"--opt-0", "7"}, args...)...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := GetAll[int](c, "opt-0"); len(got) != 11 || got[0] != 7 {
+		t.Errorf("expected the env and default values of opt-0 to be replaced, got %v", got)
+	}
+	check(c)
+
+	_, err = in.ParseThese("--labels", "a=1", "--labels", "b=2", "--labels", "a=3", "sub-0")
+	var dke DuplicateKeyError
+	if !errors.As(err, &dke) || dke.Key != "a" {
+		t.Errorf("expected a duplicate key error, got %v", err)
+	}
+}
+
+func BenchmarkPrepareLarge(b *testing.B) {
+	in := newLargeCmd(500)
+	b.ReportAllocs()
+	for range b.N {
+		in.prepareAndValidate()
+	}
+}
+
+func BenchmarkParseLarge(b *testing.B) {
+	in := newLargeCmd(500)
+	args := largeArgs(500, 5000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if _, err := in.ParseThese(args...); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLookupLarge(b *testing.B) {
+	in := newLargeCmd(500)
+	c, err := in.ParseThese(largeArgs(500, 5000)...)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := range b.N {
+		_, _ = Lookup[int](c, "opt-"+strconv.Itoa(i%500))
+	}
+}
+
+func BenchmarkGetAllLarge(b *testing.B) {
+	in := newLargeCmd(500)
+	c, err := in.ParseThese(largeArgs(500, 5000)...)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for range b.N {
+		_ = GetAll[string](c, "list")
+	}
+}
+
+func TestOptLookups(t *testing.T) {
+	in := NewCmd("program").
+		Opt(NewOpt("a").Required()).
+		Opt(NewOpt("b"))
+
+	// with both options present
+	{
+		c := in.ParseTheseOrExit("-ahello", "-bworld")
+		// straight getting the opts
+		{
+			optA := Get[string](c, "a")
+			if optA != "hello" {
+				t.Errorf(`expected "hello" for option a, got "%s"`, optA)
+			}
+			optB := Get[string](c, "b")
+			if optB != "world" {
+				t.Errorf(`expected "world" for option b, got "%s"`, optB)
+			}
+		}
+		// above should be identical with lookups
+		{
+			optA, ok := Lookup[string](c, "a")
+			if optA != "hello" || !ok {
+				t.Errorf(`expected ("hello", true) for option a lookup, got ("%s", %v)`, optA, ok)
+			}
+			optB, ok := Lookup[string](c, "b")
+			if optB != "world" || !ok {
+				t.Errorf(`expected ("world", true) for option b lookup, got ("%s", %v)`, optB, ok)
 			}
 		}
 	}
@@ -1120,3 +2970,88 @@ func ttCase() string {
 	_, _, line, _ := runtime.Caller(1)
 	return fmt.Sprintf("tt:%d", line)
 }
+
+// TestSchemaConcurrentParsing parses against one Schema from many goroutines at once. It
+// should be run with the race detector ("go test -race") to check for data races.
+func TestSchemaConcurrentParsing(t *testing.T) {
+	t.Setenv("SCHEMA_LEVEL", "3")
+	s := NewCmd("app").
+		Opt(NewBoolOpt("verbose").Short('v')).
+		Opt(NewIntOpt("level").Env("SCHEMA_LEVEL").Default("1")).
+		Opt(NewListOpt("tag", nil)).
+		Subcmd(NewCmd("run").
+			Opt(NewIntOpt("jobs").Short('j').Default("1")).
+			Arg(NewArg("target").Required())).
+		Subcmd(NewCmd("stop").
+			Arg(NewArg("pid").WithParser(ParseInt).WithValidators(InRange(1, 1<<22)))).
+		Prepare()
+
+	const goroutines = 16
+	done := make(chan struct{})
+	for g := range goroutines {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for i := range 200 {
+				n := strconv.Itoa(g*1000 + i)
+				c, err := s.ParseThese("-v", "--tag", n+",x", "run", "-j", n, "t"+n)
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				if got := Get[int](c, "level"); got != 3 {
+					t.Errorf("expected level 3 from the env, got %d", got)
+				}
+				if got := GetAll[string](c, "tag"); !slices.Equal(got, []string{n, "x"}) {
+					t.Errorf("expected tags [%s x], got %v", n, got)
+				}
+				if got := Get[int](c.Subcmd, "jobs"); strconv.Itoa(got) != n {
+					t.Errorf("expected %s jobs, got %d", n, got)
+				}
+				if got := Get[string](c.Subcmd, "target"); got != "t"+n {
+					t.Errorf("expected target t%s, got %s", n, got)
+				}
+
+				if _, err := s.ParseThese("stop", "-h"); !errors.As(err, new(HelpOrVersionRequested)) {
+					t.Errorf("expected a help request, got %v", err)
+				}
+				if _, err := s.ParseThese("stop", "0"); !errors.As(err, new(InvalidValueError)) {
+					t.Errorf("expected an invalid value error, got %v", err)
+				}
+			}
+		}()
+	}
+	for range goroutines {
+		<-done
+	}
+}
+
+func TestSchemaIsolation(t *testing.T) {
+	base := NewCmd("app").Opt(NewOpt("a")).Subcmd(NewCmd("sub"))
+	s := base.Prepare()
+
+	// These share their option and subcommand slices with base (and each other), so
+	// adding to them writes to the same spots that the Schema would have used.
+	_ = base.Opt(NewOpt("b"))
+	_ = base.Subcmd(NewCmd("other"))
+	base.Subcmds[0].Name = "renamed"
+
+	if len(base.Opts) != 1 || base.isPrepped {
+		t.Errorf("expected Prepare not to modify the command it was called on")
+	}
+	if _, err := s.ParseThese("-h"); !errors.As(err, new(HelpOrVersionRequested)) {
+		t.Errorf("expected a help request, got %v", err)
+	}
+	if _, err := s.ParseThese("--b", "1", "sub"); !errors.As(err, new(UnknownOptionError)) {
+		t.Errorf("expected an unknown option error, got %v", err)
+	}
+	c, err := s.ParseThese("--a", "1", "sub")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Subcmd == nil || c.Subcmd.Name != "sub" {
+		t.Errorf("expected the sub subcommand, got %+v", c.Subcmd)
+	}
+	if _, err := s.ParseThese("other"); !errors.As(err, new(UnknownSubcmdError)) {
+		t.Errorf("expected an unknown subcommand error, got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// DefaultConfigPrinter prints every input on the parsed command path along with its
+// resolved value and where that value came from. Inputs that are help, version, or
+// config printing inputs themselves are left out, and the values of secret inputs are
+// redacted. If src has a value of "json", the printout will be a JSON array with one
+// object per input. Otherwise, it will be a table meant for humans.
+func DefaultConfigPrinter(src Input, in *CommandInfo, c *Command) string {
+	entries := resolvedConfig(in, c)
+	if v, _ := src.Value.(string); v == "json" {
+		b, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return "error: " + err.Error() + "\n"
+		}
+		return string(b) + "\n"
+	}
+
+	var u strings.Builder
+	tw := tabwriter.NewWriter(&u, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "command\tinput\tvalue\tsource")
+	for _, e := range entries {
+		src := e.Source
+		if src == "" {
+			src = "(not set)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Command, e.Name, e.Value, src)
+	}
+	tw.Flush()
+	return u.String()
+}
+
+// configEntry is the resolved value of a single input for a config printout.
+type configEntry struct {
+	Command  string        `json:"command"`
+	ID       string        `json:"id"`
+	Name     string        `json:"name"`
+	Value    string        `json:"value"`
+	RawValue string        `json:"raw"`
+	Source   string        `json:"source"`
+	From     *configSource `json:"from,omitempty"`
+}
+
+// configSource is the JSON form of a [ParsedFrom].
+type configSource struct {
+	Env     string `json:"env,omitempty"`
+	Opt     string `json:"opt,omitempty"`
+	Arg     int    `json:"arg,omitempty"`
+	Default bool   `json:"default,omitempty"`
+	Prompt  bool   `json:"prompt,omitempty"`
+}
+
+func resolvedConfig(in *CommandInfo, c *Command) []configEntry {
+	var entries []configEntry
+	walkParsed(in, c, func(in *CommandInfo, c *Command) {
+		cmdPath := strings.Join(in.Path, " ")
+		for _, infos := range [2][]InputInfo{in.Opts, in.Args} {
+			for i := range infos {
+				info := &infos[i]
+				if info.HelpGen != nil || info.Versioner != nil || info.ConfigPrinter != nil {
+					continue
+				}
+				e := configEntry{
+					Command: cmdPath,
+					ID:      info.ID,
+					Name:    info.displayName(),
+				}
+				if pi, ok := lastInput(c, info.ID); ok {
+					e.Value, e.RawValue = formatValue(info, pi.Value), pi.RawValue
+					if info.Separator != "" || info.IsMapOpt {
+						e.Value, e.RawValue = joinedValues(c, info)
+					}
+					if pi.IsSecret {
+						e.Value, e.RawValue = redacted, redacted
+					}
+					e.Source = pi.From.String()
+					e.From = &configSource{
+						Env:     pi.From.Env,
+						Opt:     pi.From.Opt,
+						Arg:     pi.From.Arg,
+						Default: pi.From.Default,
+						Prompt:  pi.From.Prompt,
+					}
+				}
+				entries = append(entries, e)
+			}
+		}
+	})
+	return entries
+}
+
+func lastInput(c *Command, id string) (Input, bool) {
+	if i := c.lastPos(id); i != -1 {
+		return c.Inputs[i], true
+	}
+	return Input{}, false
+}
+
+// formatValue returns a parsed value of the given input as a string, using the input's
+// ValueFormatter if it has one.
+func formatValue(info *InputInfo, v any) string {
+	if info.ValueFormatter != nil {
+		return info.ValueFormatter(v)
+	}
+	return fmt.Sprint(v)
+}
+
+// joinedValues returns every parsed value and raw value for the given input as comma
+// separated lists. This is used for list and map inputs where every value is relevant.
+func joinedValues(c *Command, info *InputInfo) (string, string) {
+	var vals, raws []string
+	for i := c.firstPos(info.ID); i != -1; i = c.nextPos(i) {
+		if kv, ok := c.Inputs[i].Value.(KeyValue); ok {
+			vals = append(vals, kv.Key+"="+formatValue(info, kv.Value))
+		} else {
+			vals = append(vals, formatValue(info, c.Inputs[i].Value))
+		}
+		raws = append(raws, c.Inputs[i].RawValue)
+	}
+	return strings.Join(vals, ","), strings.Join(raws, ",")
+}
+
+func parseConfigFormat(s string) (any, error) {
+	switch s {
+	case "", "text":
+		return "text", nil
+	case "json":
+		return "json", nil
+	default:
+		return nil, fmt.Errorf("unknown config format '%s' (expected 'text' or 'json')", s)
+	}
+}
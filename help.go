@@ -64,8 +64,8 @@ func DefaultShortHelp(c *CommandInfo) string {
 			if o.IsRequired {
 				desc += " (required)"
 			}
-			if o.HasStrDefault {
-				desc += " (default: " + o.StrDefault + ")"
+			if dv, ok := o.helpDefault(); ok {
+				desc += " (default: " + dv + ")"
 			}
 			if o.EnvVar != "" {
 				desc += " [$" + o.EnvVar + "]"
@@ -96,8 +96,8 @@ func DefaultShortHelp(c *CommandInfo) string {
 			if o.IsRequired {
 				desc += " (required)"
 			}
-			if o.HasStrDefault {
-				desc += " (default: " + o.StrDefault + ")"
+			if dv, ok := o.helpDefault(); ok {
+				desc += " (default: " + dv + ")"
 			}
 			if o.EnvVar != "" {
 				desc += " [$" + o.EnvVar + "]"
@@ -135,8 +135,8 @@ func DefaultShortHelp(c *CommandInfo) string {
 			if a.IsRequired {
 				desc += " (required)"
 			}
-			if a.HasStrDefault {
-				desc += " (default: " + a.StrDefault + ")"
+			if dv, ok := a.helpDefault(); ok {
+				desc += " (default: " + dv + ")"
 			}
 			if a.EnvVar != "" {
 				desc += " [$" + a.EnvVar + "]"
@@ -204,8 +204,8 @@ func DefaultFullHelp(c *CommandInfo) string {
 	})
 	for i, o := range opts {
 		var extra string
-		if o.HasStrDefault {
-			extra += "\n      [default: " + o.StrDefault + "]"
+		if dv, ok := o.helpDefault(); ok {
+			extra += "\n      [default: " + dv + "]"
 		}
 		if o.EnvVar != "" {
 			extra += "\n      [env: " + o.EnvVar + "]"
@@ -249,8 +249,8 @@ func DefaultFullHelp(c *CommandInfo) string {
 		u.WriteString("\narguments:\n")
 		for i, a := range c.Args {
 			var extra string
-			if a.HasStrDefault {
-				extra += "\n      [default: " + a.StrDefault + "]"
+			if dv, ok := a.helpDefault(); ok {
+				extra += "\n      [default: " + dv + "]"
 			}
 			if a.EnvVar != "" {
 				extra += "\n      [env: " + a.EnvVar + "]"
@@ -344,6 +344,22 @@ func (o *InputInfo) leftPaddedNames() string {
 	return s
 }
 
+// helpDefault returns the default value of this input as it should appear in a help
+// message. The returned boolean will be false if there is no default value to show,
+// which is also the case for secret inputs so their default values are never shown. If
+// this input has a ValueFormatter, the default value is parsed and then formatted by it.
+func (o *InputInfo) helpDefault() (string, bool) {
+	if !o.HasStrDefault || o.IsSecret {
+		return "", false
+	}
+	if o.ValueFormatter != nil && o.ValueParser != nil {
+		if v, err := o.ValueParser(o.StrDefault); err == nil {
+			return o.ValueFormatter(v), true
+		}
+	}
+	return o.StrDefault, true
+}
+
 // optUsgArgName returns the usage text of an option argument for non-boolean options. For
 // example, if there's a string option named `file`, the usage might look something like
 // `--file <arg>` where "<arg>" is the usage argument name text.
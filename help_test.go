@@ -1,6 +1,11 @@
 package cli
 
-import "testing"
+import (
+	"log/slog"
+	"math/big"
+	"net/netip"
+	"testing"
+)
 
 func TestDefaultHelps(t *testing.T) {
 	for _, tt := range []struct {
@@ -282,6 +287,124 @@ options:
 commands:
    lorem     ipsum dolor sit amet, consectetur adipiscing.
    enim-ad   veniam, quis nostrud exercitation ullamco.
+`,
+		}, {
+			Case: ttCase(),
+			cmdInfo: New().
+				Opt(NewOpt("user").Help("Username.").Default("admin")).
+				Opt(NewOpt("token").Help("API token.").Default("hunter2").Env("TOKEN").Secret()).
+				Arg(NewArg("key").Help("Signing key.").Default("hunter3").Secret()),
+			expectedShort: `cli.test
+
+usage:
+  cli.test [options] [arguments]
+
+options:
+  -h, --help           Show this help message and exit.
+      --token  <arg>   API token. [$TOKEN]
+      --user  <arg>    Username. (default: admin)
+
+arguments:
+  [key]   Signing key.
+`,
+			expectedFull: `cli.test
+
+usage:
+  cli.test [options] [arguments]
+
+options:
+  -h, --help
+      Show this help message and exit.
+
+  --token  <arg>
+      API token.
+
+      [env: TOKEN]
+
+  --user  <arg>
+      Username.
+
+      [default: admin]
+
+arguments:
+  [key]
+      Signing key.
+`,
+		},
+		{
+			Case: ttCase(),
+			cmdInfo: New().
+				Opt(NewByteSizeOpt("cache").Help("Cache size.").Default("268435456")).
+				Opt(NewByteSizeOpt("chunk").Help("Chunk size.").Default("1.5M")),
+			expectedShort: `cli.test
+
+usage:
+  cli.test [options]
+
+options:
+      --cache  <arg>   Cache size. (default: 256MiB)
+      --chunk  <arg>   Chunk size. (default: 1500kB)
+  -h, --help           Show this help message and exit.
+`,
+			expectedFull: `cli.test
+
+usage:
+  cli.test [options]
+
+options:
+  --cache  <arg>
+      Cache size.
+
+      [default: 256MiB]
+
+  --chunk  <arg>
+      Chunk size.
+
+      [default: 1500kB]
+
+  -h, --help
+      Show this help message and exit.
+`,
+		}, {
+			Case: ttCase(),
+			cmdInfo: New().
+				Opt(NewTextOpt[slog.Level]("level").Help("Log level.").Default("warn")).
+				Opt(NewOpt("addr").WithParser(ParseText[netip.Addr]).WithFormatter(FormatText).Help("Address.").Default("::FFFF:10.0.0.1")).
+				Opt(NewTextPtrOpt[big.Int]("big").Help("Big number.").Default("0x100")),
+			expectedShort: `cli.test
+
+usage:
+  cli.test [options]
+
+options:
+      --addr  <arg>    Address. (default: ::ffff:10.0.0.1)
+      --big  <arg>     Big number. (default: 256)
+  -h, --help           Show this help message and exit.
+      --level  <arg>   Log level. (default: WARN)
+`,
+			expectedFull: `cli.test
+
+usage:
+  cli.test [options]
+
+options:
+  --addr  <arg>
+      Address.
+
+      [default: ::ffff:10.0.0.1]
+
+  --big  <arg>
+      Big number.
+
+      [default: 256]
+
+  -h, --help
+      Show this help message and exit.
+
+  --level  <arg>
+      Log level.
+
+      [default: WARN]
 `,
 		},
 	} {
@@ -0,0 +1,181 @@
+package cli
+
+import "slices"
+
+// indexThreshold is the number of parsed inputs a command needs to have before the parser
+// indexes them by ID. Scanning a handful of inputs is faster than maintaining an index.
+const indexThreshold = 32
+
+// inputIndex maps input IDs to the positions of their parsed values in a Command's Inputs
+// so that lookups don't have to scan every input. It's only valid for the Inputs it was
+// built for, which is checked with the length and address of the first element. If the
+// Inputs are replaced or appended to by anything other than the parser, the index is
+// ignored and lookups fall back to scanning. Changes made to the elements in place (such
+// as changing an Input's ID) can't be detected this way, so they aren't seen by lookups
+// that use the index.
+type inputIndex struct {
+	ids   map[string]inputPositions
+	next  []int32 // the position of the next value with the same ID, or -1
+	keys  map[inputKey]struct{}
+	n     int
+	first *Input
+}
+
+// inputKey identifies a key of a map option's value from a given source tier. It's used
+// to check for duplicate keys without scanning every value of the option.
+type inputKey struct {
+	id, key string
+	tier    int
+}
+
+// inputPositions holds the positions of the first and last values for an input ID.
+type inputPositions struct {
+	first, last int32
+}
+
+// validIndex returns the index of c's Inputs, or nil if there is no index or if it's stale.
+func (c *Command) validIndex() *inputIndex {
+	ix := c.index
+	if ix == nil || ix.n != len(c.Inputs) || (ix.n > 0 && ix.first != &c.Inputs[0]) {
+		return nil
+	}
+	return ix
+}
+
+// addInput appends in to c's Inputs while keeping the index up to date. The index is
+// created once there are enough inputs to make it worthwhile.
+func (c *Command) addInput(in Input) {
+	ix := c.validIndex()
+	c.Inputs = append(c.Inputs, in)
+	if ix == nil {
+		if len(c.Inputs) >= indexThreshold {
+			c.reindex()
+		}
+		return
+	}
+	pos := int32(len(c.Inputs) - 1)
+	ix.next = append(ix.next, -1)
+	if p, ok := ix.ids[in.ID]; ok {
+		ix.next[p.last] = pos
+		ix.ids[in.ID] = inputPositions{first: p.first, last: pos}
+	} else {
+		ix.ids[in.ID] = inputPositions{first: pos, last: pos}
+	}
+	ix.addKey(in)
+	ix.n, ix.first = len(c.Inputs), &c.Inputs[0]
+}
+
+// deleteInputs removes every input with the given ID for which del returns true while
+// keeping the index up to date.
+func (c *Command) deleteInputs(id string, del func(Input) bool) {
+	c.Inputs = slices.DeleteFunc(c.Inputs, func(in Input) bool {
+		return in.ID == id && del(in)
+	})
+	if c.index != nil {
+		c.reindex()
+	}
+}
+
+// reindex builds a new index for c's Inputs.
+func (c *Command) reindex() {
+	ix := &inputIndex{
+		ids:  make(map[string]inputPositions),
+		next: make([]int32, len(c.Inputs), cap(c.Inputs)),
+		n:    len(c.Inputs),
+	}
+	if ix.n > 0 {
+		ix.first = &c.Inputs[0]
+	}
+	for i := range c.Inputs {
+		ix.next[i] = -1
+		pos := int32(i)
+		if p, ok := ix.ids[c.Inputs[i].ID]; ok {
+			ix.next[p.last] = pos
+			ix.ids[c.Inputs[i].ID] = inputPositions{first: p.first, last: pos}
+		} else {
+			ix.ids[c.Inputs[i].ID] = inputPositions{first: pos, last: pos}
+		}
+		ix.addKey(c.Inputs[i])
+	}
+	c.index = ix
+}
+
+// addKey records the key of in's value if it's from a map option.
+func (ix *inputIndex) addKey(in Input) {
+	kv, ok := in.Value.(KeyValue)
+	if !ok {
+		return
+	}
+	if ix.keys == nil {
+		ix.keys = make(map[inputKey]struct{})
+	}
+	ix.keys[inputKey{id: in.ID, key: kv.Key, tier: in.From.tier()}] = struct{}{}
+}
+
+// hasKey reports whether c's Inputs has a map option value with the given ID and key
+// from a source of the given tier.
+func (c *Command) hasKey(id, key string, tier int) bool {
+	if ix := c.validIndex(); ix != nil {
+		_, ok := ix.keys[inputKey{id: id, key: key, tier: tier}]
+		return ok
+	}
+	for i := range c.Inputs {
+		in := &c.Inputs[i]
+		if in.ID == id && in.From.tier() == tier {
+			if kv, ok := in.Value.(KeyValue); ok && kv.Key == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// firstPos returns the position of the first parsed value with the given ID in c's
+// Inputs, or -1 if there isn't one. Along with nextPos, it allows all values for an ID to
+// be visited in order: "for i := c.firstPos(id); i != -1; i = c.nextPos(i)".
+func (c *Command) firstPos(id string) int {
+	if ix := c.validIndex(); ix != nil {
+		if p, ok := ix.ids[id]; ok {
+			return int(p.first)
+		}
+		return -1
+	}
+	for i := range c.Inputs {
+		if c.Inputs[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// nextPos returns the position of the next parsed value after pos with the same ID as the
+// one at pos, or -1 if there isn't one.
+func (c *Command) nextPos(pos int) int {
+	if ix := c.validIndex(); ix != nil {
+		return int(ix.next[pos])
+	}
+	id := c.Inputs[pos].ID
+	for i := pos + 1; i < len(c.Inputs); i++ {
+		if c.Inputs[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// lastPos returns the position of the last parsed value with the given ID in c's Inputs,
+// or -1 if there isn't one.
+func (c *Command) lastPos(id string) int {
+	if ix := c.validIndex(); ix != nil {
+		if p, ok := ix.ids[id]; ok {
+			return int(p.last)
+		}
+		return -1
+	}
+	for i := len(c.Inputs) - 1; i >= 0; i-- {
+		if c.Inputs[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
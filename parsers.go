@@ -1,10 +1,25 @@
 package cli
 
 import (
+	"cmp"
+	"encoding"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/big"
+	"math/bits"
+	"net"
+	"net/netip"
 	"net/url"
 	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -62,6 +77,124 @@ func ParseUint(s string) (any, error) {
 	return uint(u64), nil
 }
 
+// ParseInt8 returns the int8 value represented by the given string.
+// It unwraps any [strconv.NumError] returned by [strconv.ParseInt] for
+// a slightly cleaner error message.
+func ParseInt8(s string) (any, error) {
+	i64, err := strconv.ParseInt(s, 0, 8)
+	if err != nil {
+		return 0, numError(err)
+	}
+	return int8(i64), nil
+}
+
+// ParseInt16 returns the int16 value represented by the given string.
+// It unwraps any [strconv.NumError] returned by [strconv.ParseInt] for
+// a slightly cleaner error message.
+func ParseInt16(s string) (any, error) {
+	i64, err := strconv.ParseInt(s, 0, 16)
+	if err != nil {
+		return 0, numError(err)
+	}
+	return int16(i64), nil
+}
+
+// ParseInt32 returns the int32 value represented by the given string.
+// It unwraps any [strconv.NumError] returned by [strconv.ParseInt] for
+// a slightly cleaner error message.
+func ParseInt32(s string) (any, error) {
+	i64, err := strconv.ParseInt(s, 0, 32)
+	if err != nil {
+		return 0, numError(err)
+	}
+	return int32(i64), nil
+}
+
+// ParseInt64 returns the int64 value represented by the given string.
+// It unwraps any [strconv.NumError] returned by [strconv.ParseInt] for
+// a slightly cleaner error message.
+func ParseInt64(s string) (any, error) {
+	i64, err := strconv.ParseInt(s, 0, 64)
+	if err != nil {
+		return 0, numError(err)
+	}
+	return int64(i64), nil
+}
+
+// ParseUint8 returns the uint8 value represented by the given string.
+// It unwraps any [strconv.NumError] returned by [strconv.ParseUint] for
+// a slightly cleaner error message.
+func ParseUint8(s string) (any, error) {
+	u64, err := strconv.ParseUint(s, 0, 8)
+	if err != nil {
+		return 0, numError(err)
+	}
+	return uint8(u64), nil
+}
+
+// ParseUint16 returns the uint16 value represented by the given string.
+// It unwraps any [strconv.NumError] returned by [strconv.ParseUint] for
+// a slightly cleaner error message.
+func ParseUint16(s string) (any, error) {
+	u64, err := strconv.ParseUint(s, 0, 16)
+	if err != nil {
+		return 0, numError(err)
+	}
+	return uint16(u64), nil
+}
+
+// ParseUint32 returns the uint32 value represented by the given string.
+// It unwraps any [strconv.NumError] returned by [strconv.ParseUint] for
+// a slightly cleaner error message.
+func ParseUint32(s string) (any, error) {
+	u64, err := strconv.ParseUint(s, 0, 32)
+	if err != nil {
+		return 0, numError(err)
+	}
+	return uint32(u64), nil
+}
+
+// ParseUint64 returns the uint64 value represented by the given string.
+// It unwraps any [strconv.NumError] returned by [strconv.ParseUint] for
+// a slightly cleaner error message.
+func ParseUint64(s string) (any, error) {
+	u64, err := strconv.ParseUint(s, 0, 64)
+	if err != nil {
+		return 0, numError(err)
+	}
+	return uint64(u64), nil
+}
+
+// ParseBigInt returns the *big.Int value represented by the given string. The base is
+// determined by the string's prefix the same way as [ParseInt] (e.g. "0x" for hex).
+func ParseBigInt(s string) (any, error) {
+	i, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		return nil, errors.New("invalid integer value")
+	}
+	return i, nil
+}
+
+// ParseBigFloat returns the *big.Float value represented by the given string.
+// See [big.Float.Parse] for the accepted formats.
+func ParseBigFloat(s string) (any, error) {
+	f, ok := new(big.Float).SetString(s)
+	if !ok {
+		return nil, errors.New("invalid floating point value")
+	}
+	return f, nil
+}
+
+// ParseBigRat returns the *big.Rat value represented by the given string, which can
+// be a fraction such as "3/4" or a floating point number such as "0.75".
+func ParseBigRat(s string) (any, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, errors.New("invalid rational value")
+	}
+	return r, nil
+}
+
 func numError(err error) error {
 	if ne, ok := err.(*strconv.NumError); ok {
 		return ne.Err
@@ -69,12 +202,298 @@ func numError(err error) error {
 	return err
 }
 
+// byteUnits are the byte size units along with their multipliers.
+var byteUnits = [...]struct {
+	name string
+	mult uint64
+}{
+	{"EiB", 1 << 60}, {"PiB", 1 << 50}, {"TiB", 1 << 40}, {"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10},
+	{"EB", 1e18}, {"PB", 1e15}, {"TB", 1e12}, {"GB", 1e9}, {"MB", 1e6}, {"kB", 1e3},
+	{"B", 1},
+}
+
+// ParseByteSize returns the uint64 number of bytes represented by the given string. The
+// number can have a decimal point and can be followed by a unit (case insensitive). The
+// SI units (k, M, G, T, P, E, optionally followed by B) are powers of 1000, and the IEC
+// units (Ki, Mi, Gi, Ti, Pi, Ei, optionally followed by B) are powers of 1024. A plain
+// "B" or no unit at all means bytes. For example, "10MiB", "1.5G", and "512k" are all
+// valid. Any fractional byte left over is dropped.
+func ParseByteSize(s string) (any, error) {
+	numEnd := 0
+	for numEnd < len(s) && (s[numEnd] >= '0' && s[numEnd] <= '9' || s[numEnd] == '.') {
+		numEnd++
+	}
+	numStr := s[:numEnd]
+	unit := strings.ToLower(strings.TrimSpace(s[numEnd:]))
+	if numStr == "" || strings.Count(numStr, ".") > 1 || numStr == "." {
+		return uint64(0), errors.New("invalid byte size")
+	}
+
+	var mult uint64
+	switch unit {
+	case "":
+		mult = 1
+	case "k", "m", "g", "t", "p", "e", "ki", "mi", "gi", "ti", "pi", "ei":
+		unit += "b"
+		fallthrough
+	default:
+		for _, u := range byteUnits {
+			if strings.EqualFold(u.name, unit) {
+				mult = u.mult
+				break
+			}
+		}
+	}
+	if mult == 0 {
+		return uint64(0), fmt.Errorf("unknown byte size unit '%s'", s[numEnd:])
+	}
+
+	// Integers are handled separately so they don't lose any precision.
+	if !strings.Contains(numStr, ".") {
+		n, err := strconv.ParseUint(numStr, 10, 64)
+		if err != nil {
+			return uint64(0), numError(err)
+		}
+		hi, lo := bits.Mul64(n, mult)
+		if hi != 0 {
+			return uint64(0), strconv.ErrRange
+		}
+		return lo, nil
+	}
+
+	r, ok := new(big.Rat).SetString(numStr)
+	if !ok {
+		return uint64(0), errors.New("invalid byte size")
+	}
+	r.Mul(r, new(big.Rat).SetUint64(mult))
+	n := new(big.Int).Quo(r.Num(), r.Denom())
+	if !n.IsUint64() {
+		return uint64(0), strconv.ErrRange
+	}
+	return n.Uint64(), nil
+}
+
+// FormatByteSize returns the given uint64 number of bytes as a string using whichever
+// unit (IEC or SI) has the largest multiplier that divides it evenly. For example, it
+// returns "1MiB" for 1048576, "1500MB" for 1500000000, and "1000KiB" for 1024000 (since
+// 1024 is larger than 1000). It's the [ValueFormatter] counterpart of [ParseByteSize].
+// Any value that isn't a uint64 is formatted with the "%v" verb.
+func FormatByteSize(v any) string {
+	n, ok := v.(uint64)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+	if n == 0 {
+		return "0B"
+	}
+	best := byteUnits[len(byteUnits)-1]
+	for _, u := range byteUnits {
+		if n%u.mult == 0 && u.mult > best.mult {
+			best = u
+		}
+	}
+	return strconv.FormatUint(n/best.mult, 10) + best.name
+}
+
 // ParseDuration uses the standard library [time.ParseDuration] function to
 // parse and return the time.Duration value represented by the given string.
 func ParseDuration(s string) (any, error) {
 	return time.ParseDuration(s)
 }
 
+const (
+	day  = 24 * time.Hour
+	week = 7 * day
+)
+
+// durationUnits are the units accepted by [ParseExtendedDuration].
+var durationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"µs": time.Microsecond,
+	"μs": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  day,
+	"w":  week,
+}
+
+// ParseExtendedDuration returns the time.Duration value represented by the given string.
+// It accepts everything [time.ParseDuration] does along with the "d" (24 hours) and "w"
+// (7 days) units, as in "7d" or "1w2d12h". It also accepts ISO 8601 durations such as
+// "P1DT2H" or "PT1.5S" as long as they don't use years or months, which have no fixed
+// length. Either form can start with a sign.
+func ParseExtendedDuration(s string) (any, error) {
+	orig := s
+	neg := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+
+	var total big.Rat
+	var err error
+	if len(s) > 0 && (s[0] == 'P' || s[0] == 'p') {
+		err = addISODuration(&total, s[1:])
+	} else if s == "0" {
+		// A lone zero doesn't need a unit.
+	} else {
+		err = addUnitDuration(&total, s)
+	}
+	if err != nil {
+		return time.Duration(0), fmt.Errorf("invalid duration '%s': %w", orig, err)
+	}
+
+	if neg {
+		total.Neg(&total)
+	}
+	n := new(big.Int).Quo(total.Num(), total.Denom())
+	if !n.IsInt64() {
+		return time.Duration(0), fmt.Errorf("invalid duration '%s': %w", orig, strconv.ErrRange)
+	}
+	return time.Duration(n.Int64()), nil
+}
+
+// addUnitDuration adds the number of nanoseconds in a sequence of number and unit pairs
+// (as in "1d12h") to total.
+func addUnitDuration(total *big.Rat, s string) error {
+	if s == "" {
+		return errors.New("empty duration")
+	}
+	for s != "" {
+		i := 0
+		for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+			i++
+		}
+		j := i
+		for j < len(s) && !(s[j] >= '0' && s[j] <= '9' || s[j] == '.') {
+			j++
+		}
+		num, unit := s[:i], s[i:j]
+		if unit == "" {
+			return errors.New("missing unit")
+		}
+		mult, ok := durationUnits[unit]
+		if !ok {
+			return fmt.Errorf("unknown unit '%s'", unit)
+		}
+		if err := addDurationPart(total, num, mult); err != nil {
+			return err
+		}
+		s = s[j:]
+	}
+	return nil
+}
+
+// addISODuration adds the number of nanoseconds in an ISO 8601 duration (without its
+// leading "P") to total.
+func addISODuration(total *big.Rat, s string) error {
+	s = strings.ToUpper(s)
+	if s == "" {
+		return errors.New("empty duration")
+	}
+	inTime := false
+	for s != "" {
+		if s[0] == 'T' {
+			if inTime || len(s) == 1 {
+				return errors.New("misplaced 'T'")
+			}
+			inTime = true
+			s = s[1:]
+			continue
+		}
+		i := 0
+		for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.' || s[i] == ',') {
+			i++
+		}
+		if i == len(s) {
+			return errors.New("missing designator")
+		}
+		num, designator := strings.Replace(s[:i], ",", ".", 1), s[i]
+		var mult time.Duration
+		switch {
+		case designator == 'Y' && !inTime, designator == 'M' && !inTime:
+			return errors.New("years and months are not supported")
+		case designator == 'W' && !inTime:
+			mult = week
+		case designator == 'D' && !inTime:
+			mult = day
+		case designator == 'H' && inTime:
+			mult = time.Hour
+		case designator == 'M' && inTime:
+			mult = time.Minute
+		case designator == 'S' && inTime:
+			mult = time.Second
+		default:
+			return fmt.Errorf("unexpected '%c'", designator)
+		}
+		if err := addDurationPart(total, num, mult); err != nil {
+			return err
+		}
+		s = s[i+1:]
+	}
+	return nil
+}
+
+// addDurationPart adds num (a non-negative decimal number) times mult to total.
+func addDurationPart(total *big.Rat, num string, mult time.Duration) error {
+	if num == "" || num == "." || strings.Count(num, ".") > 1 {
+		return errors.New("missing number")
+	}
+	r, ok := new(big.Rat).SetString(num)
+	if !ok {
+		return fmt.Errorf("invalid number '%s'", num)
+	}
+	total.Add(total, r.Mul(r, new(big.Rat).SetInt64(int64(mult))))
+	return nil
+}
+
+// FormatExtendedDuration is a [ValueFormatter] that returns the given time.Duration in the
+// form accepted by [ParseExtendedDuration] using weeks and days where possible, as in
+// "2w", "10d", or "1d12h30m". Any value that isn't a time.Duration is formatted with the
+// "%v" verb.
+func FormatExtendedDuration(v any) string {
+	d, ok := v.(time.Duration)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+	if d == 0 || d%day != 0 && d > -day && d < day {
+		return trimDuration(d.String())
+	}
+
+	var b strings.Builder
+	if d < 0 {
+		b.WriteByte('-')
+	}
+	// Use unsigned values so the smallest negative duration can't overflow.
+	u := uint64(d)
+	if d < 0 {
+		u = -u
+	}
+	if u%uint64(week) == 0 {
+		return b.String() + strconv.FormatUint(u/uint64(week), 10) + "w"
+	}
+	b.WriteString(strconv.FormatUint(u/uint64(day), 10) + "d")
+	if rem := time.Duration(u % uint64(day)); rem != 0 {
+		b.WriteString(trimDuration(rem.String()))
+	}
+	return b.String()
+}
+
+// trimDuration removes the zero minutes and seconds from the end of a string from
+// [time.Duration.String] (e.g. "12h0m0s" becomes "12h").
+func trimDuration(s string) string {
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
+
 // NewTimeParser returns a [ValueParser] that will use the standard library
 // [time.Parse] function with the given layout string to parse and return a
 // time.Time from a given string.
@@ -84,12 +503,285 @@ func NewTimeParser(layout string) ValueParser {
 	}
 }
 
+// DefaultTimeLayouts are the layouts used by [NewFlexTimeParser] when none are given.
+var DefaultTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	time.DateOnly,
+}
+
+// FlexTimeConfig is used to pass customization values to [NewFlexTimeParser].
+type FlexTimeConfig struct {
+	// Layouts are the [time.Parse] layouts to try, in order. If this is empty,
+	// [DefaultTimeLayouts] is used.
+	Layouts []string
+	// Location is the location used for layouts that don't include a time zone, for Unix
+	// timestamps, and for the start of the day when using "today" and friends. If this is
+	// nil, [time.Local] is used.
+	Location *time.Location
+	// Now returns the current time for relative times. If this is nil, [time.Now] is used.
+	Now func() time.Time
+}
+
+// minUnixDigits is the fewest digits a Unix timestamp without an "@" can have in
+// [NewFlexTimeParser].
+const minUnixDigits = 10
+
+// NewFlexTimeParser returns a [ValueParser] that parses and returns a time.Time from any
+// of the following forms (which are tried in this order):
+//   - "now", "today", "yesterday", or "tomorrow", where the last three are the start of
+//     the day in the configured location.
+//   - A time in any of the configured layouts.
+//   - A Unix timestamp in seconds, or in milliseconds if it is at least 1e11 (which is
+//     past the year 5000 in seconds). A timestamp must either have at least 10 digits
+//     (which covers every time since September 2001) or start with "@" as in "@86400",
+//     so that something like "20240601" is never mistaken for one.
+//   - A duration relative to now that starts with a sign, as in "-2h" or "+7d". See
+//     [ParseExtendedDuration] for the duration syntax.
+func NewFlexTimeParser(cfg FlexTimeConfig) ValueParser {
+	if len(cfg.Layouts) == 0 {
+		cfg.Layouts = DefaultTimeLayouts
+	}
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return func(s string) (any, error) {
+		switch s {
+		case "now":
+			return cfg.Now().In(cfg.Location), nil
+		case "today", "yesterday", "tomorrow":
+			y, m, d := cfg.Now().In(cfg.Location).Date()
+			switch s {
+			case "yesterday":
+				d--
+			case "tomorrow":
+				d++
+			}
+			return time.Date(y, m, d, 0, 0, 0, 0, cfg.Location), nil
+		}
+
+		for _, layout := range cfg.Layouts {
+			if t, err := time.ParseInLocation(layout, s, cfg.Location); err == nil {
+				return t, nil
+			}
+		}
+
+		if digits, marked := strings.CutPrefix(s, "@"); marked || len(s) >= minUnixDigits {
+			if n, err := strconv.ParseInt(digits, 10, 64); err == nil && n >= 0 {
+				if n >= 1e11 {
+					return time.UnixMilli(n).In(cfg.Location), nil
+				}
+				return time.Unix(n, 0).In(cfg.Location), nil
+			}
+		}
+
+		if len(s) > 1 && (s[0] == '-' || s[0] == '+') {
+			if d, err := ParseExtendedDuration(s); err == nil {
+				return cfg.Now().In(cfg.Location).Add(d.(time.Duration)), nil
+			}
+		}
+
+		return time.Time{}, fmt.Errorf(`unrecognized time (expected a time such as "%s", `+
+			`a Unix timestamp such as "@1700000000", or a relative time such as "-2h" or "yesterday")`, cfg.Layouts[0])
+	}
+}
+
 // ParseURL uses the standard library [url.Parse] function to parse
 // and return the *url.URL value represented by the given string.
 func ParseURL(s string) (any, error) {
 	return url.Parse(s)
 }
 
+// ParseRegexp uses the standard library [regexp.Compile] function to parse and return
+// the *regexp.Regexp value represented by the given string.
+func ParseRegexp(s string) (any, error) {
+	return regexp.Compile(s)
+}
+
+// ParseGlob returns the given string if it is a valid [path.Match] pattern.
+func ParseGlob(s string) (any, error) {
+	if _, err := path.Match(s, ""); err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+// ParseFilepathGlob returns the given string if it is a valid [filepath.Match] pattern.
+func ParseFilepathGlob(s string) (any, error) {
+	if _, err := filepath.Match(s, ""); err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+// ParseJSON uses the standard library [json.Unmarshal] function to decode the given
+// string into a value of type T and returns that value.
+func ParseJSON[T any](s string) (any, error) {
+	var v T
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return v, err
+	}
+	return v, nil
+}
+
+// ParseText parses the given string using the UnmarshalText method of a *T and returns
+// the resulting T value. This allows any type that implements [encoding.TextUnmarshaler]
+// through its pointer, such as [log/slog.Level] or [netip.Addr], to be used as a value
+// parser without a handwritten closure (e.g. "WithParser(cli.ParseText[slog.Level])").
+// For types that are meant to be used through a pointer, see [ParseTextPtr].
+func ParseText[T any, PT interface {
+	*T
+	encoding.TextUnmarshaler
+}](s string) (any, error) {
+	var v T
+	if err := PT(&v).UnmarshalText([]byte(s)); err != nil {
+		return v, err
+	}
+	return v, nil
+}
+
+// ParseTextPtr is like [ParseText] except that it returns the resulting *T instead of
+// the T value. This is for types that are meant to be used through a pointer and
+// shouldn't be copied, such as [big.Int] (e.g. "WithParser(cli.ParseTextPtr[big.Int])"
+// produces *big.Int values).
+func ParseTextPtr[T any, PT interface {
+	*T
+	encoding.TextUnmarshaler
+}](s string) (any, error) {
+	v := PT(new(T))
+	if err := v.UnmarshalText([]byte(s)); err != nil {
+		return (*T)(nil), err
+	}
+	return (*T)(v), nil
+}
+
+// FormatText is a [ValueFormatter] that returns the text form of the given value using
+// its MarshalText method if it implements [encoding.TextMarshaler] or its String method
+// if it implements [fmt.Stringer]. Otherwise, the value is formatted with the "%v" verb.
+func FormatText(v any) string {
+	switch v := v.(type) {
+	case encoding.TextMarshaler:
+		if b, err := v.MarshalText(); err == nil {
+			return string(b)
+		}
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprint(v)
+}
+
+// ParseAddr uses the standard library [netip.ParseAddr] function to parse and return the
+// IPv4 or IPv6 netip.Addr value represented by the given string.
+func ParseAddr(s string) (any, error) {
+	return netip.ParseAddr(s)
+}
+
+// ParsePrefix uses the standard library [netip.ParsePrefix] function to parse and return
+// the netip.Prefix value (CIDR notation, as in "10.0.0.0/8") represented by the given
+// string.
+func ParsePrefix(s string) (any, error) {
+	return netip.ParsePrefix(s)
+}
+
+// ParseAddrPort uses the standard library [netip.ParseAddrPort] function to parse and
+// return the netip.AddrPort value (as in "127.0.0.1:80" or "[::1]:80") represented by
+// the given string.
+func ParseAddrPort(s string) (any, error) {
+	return netip.ParseAddrPort(s)
+}
+
+// ParseMAC uses the standard library [net.ParseMAC] function to parse and return the
+// net.HardwareAddr value represented by the given string.
+func ParseMAC(s string) (any, error) {
+	return net.ParseMAC(s)
+}
+
+// NewHostPortParser returns a [ValueParser] that parses a "host:port" pair and returns
+// it as a string in the form produced by [net.JoinHostPort], which is suitable for
+// functions such as [net.Listen] and [net.Dial]. The host can be a name, an IPv4 address,
+// a bracketed IPv6 address, or empty (as in ":8080"). The port must be a number from 0
+// to 65535. If a default port is provided, it's used whenever the port is left out or
+// empty (as in "localhost:"). This includes a default port of 0, which lets the system
+// pick a free port. Otherwise, the port is required. Anything more than a single default
+// port provided is ignored.
+func NewHostPortParser(defaultPort ...uint16) ValueParser {
+	return func(s string) (any, error) {
+		host, port, err := net.SplitHostPort(s)
+		if err != nil {
+			if len(defaultPort) == 0 {
+				return "", err
+			}
+			// The port is likely missing, so try the whole thing as just a host.
+			host = s
+			if len(host) > 1 && host[0] == '[' && host[len(host)-1] == ']' {
+				host = host[1 : len(host)-1]
+			}
+			if strings.ContainsAny(host, "[]") {
+				return "", err
+			}
+			if strings.Contains(host, ":") {
+				if _, aerr := netip.ParseAddr(host); aerr != nil {
+					return "", err
+				}
+			}
+			port = strconv.FormatUint(uint64(defaultPort[0]), 10)
+		} else if port == "" && len(defaultPort) > 0 {
+			// A trailing colon with nothing after it (as in "localhost:") is also a
+			// missing port.
+			port = strconv.FormatUint(uint64(defaultPort[0]), 10)
+		}
+		if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+			return "", fmt.Errorf("invalid port '%s'", port)
+		}
+		return net.JoinHostPort(host, port), nil
+	}
+}
+
+// IPv4Only is a [Validator] that ensures a netip.Addr, netip.Prefix, or netip.AddrPort
+// value holds an IPv4 address.
+func IPv4Only(v any) error {
+	a, err := netipAddr(v)
+	if err != nil {
+		return err
+	}
+	if !a.Is4() {
+		return errors.New("must be an IPv4 address")
+	}
+	return nil
+}
+
+// IPv6Only is a [Validator] that ensures a netip.Addr, netip.Prefix, or netip.AddrPort
+// value holds an IPv6 address.
+func IPv6Only(v any) error {
+	a, err := netipAddr(v)
+	if err != nil {
+		return err
+	}
+	if !a.Is6() {
+		return errors.New("must be an IPv6 address")
+	}
+	return nil
+}
+
+func netipAddr(v any) (netip.Addr, error) {
+	switch v := v.(type) {
+	case netip.Addr:
+		return v, nil
+	case netip.Prefix:
+		return v.Addr(), nil
+	case netip.AddrPort:
+		return v.Addr(), nil
+	default:
+		return netip.Addr{}, fmt.Errorf("unexpected value type %T", v)
+	}
+}
+
 // NewFileParser returns a [ValueParser] that will treat an input string as a
 // file path and use given parser to parse the content of the file at that path.
 func NewFileParser(vp ValueParser) ValueParser {
@@ -110,3 +802,292 @@ func NewFileParser(vp ValueParser) ValueParser {
 		return vp(s)
 	}
 }
+
+// ParsePath returns the given path with a leading "~" expanded to the current user's home
+// directory and made absolute. See [filepath.Abs] for how relative paths are resolved.
+func ParsePath(s string) (any, error) {
+	return expandPath(s)
+}
+
+// ParseExistingFile is like [ParsePath] but also ensures the path is an existing file
+// that is not a directory.
+func ParseExistingFile(s string) (any, error) {
+	p, err := expandPath(s)
+	if err != nil {
+		return "", err
+	}
+	if err := FileExists(p); err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+// ParseExistingDir is like [ParsePath] but also ensures the path is an existing
+// directory.
+func ParseExistingDir(s string) (any, error) {
+	p, err := expandPath(s)
+	if err != nil {
+		return "", err
+	}
+	if err := DirExists(p); err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+// ParseNewPath is like [ParsePath] but also ensures nothing exists at the path yet.
+func ParseNewPath(s string) (any, error) {
+	p, err := expandPath(s)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Lstat(p); err == nil {
+		return "", fmt.Errorf("'%s' already exists", p)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	return p, nil
+}
+
+// ParseWritablePath is like [ParsePath] but also ensures a file can be written at the
+// path. If a file already exists there, it must not be a directory and its permissions
+// must allow writing. Otherwise, its parent directory must exist and allow writing.
+// Nothing is created or modified. The permission check only looks at the permission
+// bits, so it can't tell whether the current user is the one they apply to.
+func ParseWritablePath(s string) (any, error) {
+	p, err := expandPath(s)
+	if err != nil {
+		return "", err
+	}
+	if err := checkWritable(p); err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+// checkWritable returns an error if a file can't be written at the given path according
+// to [ParseWritablePath].
+func checkWritable(path string) error {
+	fi, err := os.Stat(path)
+	switch {
+	case err == nil:
+		if fi.IsDir() {
+			return fmt.Errorf("'%s' is a directory", path)
+		}
+	case errors.Is(err, fs.ErrNotExist):
+		dir := filepath.Dir(path)
+		if fi, err = os.Stat(dir); err != nil {
+			return err
+		}
+		if !fi.IsDir() {
+			return fmt.Errorf("'%s' is not a directory", dir)
+		}
+		path = dir
+	default:
+		return err
+	}
+	if fi.Mode().Perm()&0o222 == 0 {
+		return &fs.PathError{Op: "write", Path: path, Err: fs.ErrPermission}
+	}
+	return nil
+}
+
+// An InputFile is a file to read from, as produced by [ParseInputFile]. It isn't opened
+// until [InputFile.Open] is called.
+type InputFile struct {
+	// Path is the absolute path of the file, or "-" for stdin.
+	Path string
+
+	stdin io.Reader
+}
+
+// Open opens the file for reading. If the Path is "-", the returned reader reads stdin
+// (the same one that inputs with ReadsStdin would read) and closing it does nothing.
+func (f InputFile) Open() (io.ReadCloser, error) {
+	if f.Path == "-" {
+		r := f.stdin
+		if r == nil {
+			r = os.Stdin
+		}
+		return io.NopCloser(r), nil
+	}
+	return os.Open(f.Path)
+}
+
+// String returns the Path of the file.
+func (f InputFile) String() string {
+	return f.Path
+}
+
+// ParseInputFile returns an [InputFile] for the given path after ensuring it's an
+// existing file that is not a directory. The path is expanded just like it is in
+// [ParsePath]. A path of "-" means stdin, and an input with this value counts as the one
+// input that reads stdin in a parse (see ReadsStdin on [InputInfo]) unless the value is
+// outranked by one from a higher precedence source, such as a default value of "-" when
+// a path is given on the command line. The file isn't opened until [InputFile.Open] is
+// called.
+func ParseInputFile(s string) (any, error) {
+	if s == "-" {
+		return InputFile{Path: s}, nil
+	}
+	p, err := expandPath(s)
+	if err != nil {
+		return InputFile{}, err
+	}
+	if err := FileExists(p); err != nil {
+		return InputFile{}, err
+	}
+	return InputFile{Path: p}, nil
+}
+
+// An OutputFile is a file to write to, as produced by [ParseOutputFile]. It isn't
+// created until [OutputFile.Create] is called.
+type OutputFile struct {
+	// Path is the absolute path of the file, or "-" for stdout.
+	Path string
+}
+
+// Create creates or truncates the file and opens it for writing. If the Path is "-", the
+// returned writer writes to [os.Stdout] and closing it does nothing.
+func (f OutputFile) Create() (io.WriteCloser, error) {
+	if f.Path == "-" {
+		return nopWriteCloser{os.Stdout}, nil
+	}
+	return os.Create(f.Path)
+}
+
+// String returns the Path of the file.
+func (f OutputFile) String() string {
+	return f.Path
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// ParseOutputFile returns an [OutputFile] for the given path after ensuring a file can be
+// written there just like [ParseWritablePath] does. The path is expanded just like it is
+// in [ParsePath], and a path of "-" means stdout. The file isn't created (or truncated)
+// until [OutputFile.Create] is called.
+func ParseOutputFile(s string) (any, error) {
+	if s == "-" {
+		return OutputFile{Path: s}, nil
+	}
+	p, err := expandPath(s)
+	if err != nil {
+		return OutputFile{}, err
+	}
+	if err := checkWritable(p); err != nil {
+		return OutputFile{}, err
+	}
+	return OutputFile{Path: p}, nil
+}
+
+// expandPath replaces a leading "~" in the given path with the current user's home
+// directory and returns the absolute form of the result.
+func expandPath(s string) (string, error) {
+	if s == "" {
+		return "", errors.New("empty path")
+	}
+	if s == "~" || strings.HasPrefix(s, "~/") || strings.HasPrefix(s, "~"+string(filepath.Separator)) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		s = filepath.Join(home, s[1:])
+	}
+	return filepath.Abs(s)
+}
+
+// InRange returns a [Validator] that ensures a value of type T is within the inclusive
+// range of lo to hi.
+func InRange[T cmp.Ordered](lo, hi T) Validator {
+	return func(v any) error {
+		t, ok := v.(T)
+		if !ok {
+			return fmt.Errorf("unexpected value type %T", v)
+		}
+		if t < lo || t > hi {
+			return fmt.Errorf("must be between %v and %v", lo, hi)
+		}
+		return nil
+	}
+}
+
+// OneOf returns a [Validator] that ensures a value of type T is equal to one of the
+// given values.
+func OneOf[T comparable](vals ...T) Validator {
+	return func(v any) error {
+		t, ok := v.(T)
+		if !ok {
+			return fmt.Errorf("unexpected value type %T", v)
+		}
+		if !slices.Contains(vals, t) {
+			return fmt.Errorf("must be one of %v", vals)
+		}
+		return nil
+	}
+}
+
+// NotEmpty is a [Validator] that ensures a string value is not empty.
+func NotEmpty(v any) error {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("unexpected value type %T", v)
+	}
+	if s == "" {
+		return errors.New("must not be empty")
+	}
+	return nil
+}
+
+// MatchesRegexp returns a [Validator] that ensures a string value matches the given
+// regular expression.
+func MatchesRegexp(re *regexp.Regexp) Validator {
+	return func(v any) error {
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("unexpected value type %T", v)
+		}
+		if !re.MatchString(s) {
+			return fmt.Errorf("must match the pattern '%s'", re)
+		}
+		return nil
+	}
+}
+
+// FileExists is a [Validator] that ensures a string value is the path of an existing
+// file that is not a directory.
+func FileExists(v any) error {
+	path, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("unexpected value type %T", v)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		return fmt.Errorf("'%s' is a directory", path)
+	}
+	return nil
+}
+
+// DirExists is a [Validator] that ensures a string value is the path of an existing
+// directory.
+func DirExists(v any) error {
+	path, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("unexpected value type %T", v)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("'%s' is not a directory", path)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// A Prompter is used by the parser to ask for the value of a required input that is
+// missing after parsing a command. The input's HelpBlurb (or its name if there is no
+// blurb) is written to Out as the prompt, and a single line is read from In as the raw
+// value. An empty line is treated as no value at all. See [CommandInfo.PromptMissing].
+type Prompter struct {
+	In  io.Reader
+	Out io.Writer
+
+	// IsInteractive reports whether the parser should prompt at all. If this is nil, the
+	// parser will always prompt for missing required inputs.
+	IsInteractive func() bool
+
+	// SetEcho is called with false before reading the value of a secret input and with
+	// true after it has been read. It's meant to stop a terminal from showing what is
+	// being typed. If this is nil, secret values are read like any other value.
+	SetEcho func(on bool) error
+}
+
+// NewTermPrompter returns a [Prompter] that reads values from Stdin and writes prompts to
+// Stderr. It will only prompt if Stdin is a terminal. On systems that have the "stty"
+// program (such as Linux, macOS, and the BSDs), it runs it to turn off echoing while a
+// secret value is being typed. Where "stty" can't be found (such as on Windows or in a
+// minimal container), SetEcho is left nil, so secret values are read like any other
+// value and will be visible as they're typed.
+func NewTermPrompter() *Prompter {
+	pr := &Prompter{
+		In:  os.Stdin,
+		Out: os.Stderr,
+		IsInteractive: func() bool {
+			fi, err := os.Stdin.Stat()
+			return err == nil && fi.Mode()&os.ModeCharDevice != 0
+		},
+	}
+	if stty, err := exec.LookPath("stty"); err == nil {
+		pr.SetEcho = func(on bool) error {
+			arg := "echo"
+			if !on {
+				arg = "-echo"
+			}
+			cmd := exec.Command(stty, arg)
+			cmd.Stdin = os.Stdin
+			return cmd.Run()
+		}
+	}
+	return pr
+}
+
+// prompt writes the prompt for the given input and reads a line in response. The
+// returned boolean will be false if nothing was entered or if this Prompter shouldn't
+// be prompting at all.
+func (pr *Prompter) prompt(in *InputInfo) (string, bool, error) {
+	if pr.IsInteractive != nil && !pr.IsInteractive() {
+		return "", false, nil
+	}
+
+	msg := in.HelpBlurb
+	if msg == "" {
+		msg = in.displayName()
+	}
+	if _, err := fmt.Fprint(pr.Out, msg+": "); err != nil {
+		return "", false, err
+	}
+
+	if in.IsSecret && pr.SetEcho != nil {
+		if err := pr.SetEcho(false); err != nil {
+			return "", false, fmt.Errorf("turning off echo: %w", err)
+		}
+		defer func() {
+			_ = pr.SetEcho(true)
+			// The user's newline wasn't echoed, so we have to write our own.
+			fmt.Fprintln(pr.Out)
+		}()
+	}
+
+	line, err := readLine(pr.In)
+	if err != nil {
+		return "", false, err
+	}
+	return line, line != "", nil
+}
+
+// readLine reads a single line from r without the line ending. It reads one byte at a
+// time so that nothing past the end of the line is consumed. Reaching the end of r is
+// not an error.
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	var b [1]byte
+	for {
+		n, err := r.Read(b[:])
+		if n > 0 {
+			if b[0] == '\n' {
+				break
+			}
+			line = append(line, b[0])
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	if len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
+	}
+	return string(line), nil
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"os"
+	"slices"
+)
+
+// A Schema is a CommandInfo that has been prepared for parsing ahead of time by
+// [CommandInfo.Prepare]. A CommandInfo prepares itself the first time it's parsed, which
+// means it can't be parsed from more than one goroutine at once. A Schema, on the other
+// hand, is never modified after it's created, so any number of goroutines can parse
+// command lines against the same Schema at the same time. This is useful for something
+// like a server that parses a command line for each request it handles.
+//
+// Note that anything a Schema refers to that does its own work while parsing (such as a
+// [Prompter], a Stdin reader, a custom value parser, or a validator) also needs to be
+// safe for concurrent use for parsing to be. This is especially true of Binders (see
+// [InputInfo.Bind]): each one writes to the same destination on every parse, so
+// concurrent parses of a Schema with any Binders will race on those destinations and
+// overwrite each other's values. A Schema that is parsed concurrently should leave out
+// Binders and read values from each parsed [Command] (with [Get] and friends) instead.
+type Schema struct {
+	info CommandInfo
+}
+
+// Prepare validates this command and all of its subcommands and returns an immutable
+// [Schema] for them that's safe to parse concurrently. The Schema has its own copy of the
+// command tree, so adding to this CommandInfo afterwards won't affect it. Just like
+// [CommandInfo.ParseThese], this method will panic if there are any schema errors.
+func (in CommandInfo) Prepare() *Schema {
+	s := &Schema{info: cloneCmd(in)}
+	s.info.prepareAndValidate()
+	s.info.isPrepped = true
+	return s
+}
+
+// cloneCmd returns a copy of c that doesn't share any of its usage lines, options,
+// positional arguments, or subcommands (at any level) with c.
+func cloneCmd(c CommandInfo) CommandInfo {
+	c.Path = slices.Clone(c.Path)
+	c.HelpUsage = slices.Clone(c.HelpUsage)
+	c.Opts = slices.Clone(c.Opts)
+	c.Args = slices.Clone(c.Args)
+	c.Subcmds = slices.Clone(c.Subcmds)
+	for i := range c.Subcmds {
+		c.Subcmds[i] = cloneCmd(c.Subcmds[i])
+	}
+	return c
+}
+
+// Parse calls [Schema.ParseThese] using os.Args as the command line arguments.
+func (s *Schema) Parse() (*Command, error) {
+	return s.ParseThese(os.Args[1:]...)
+}
+
+// ParseThese parses args against this Schema. It works the same way as
+// [CommandInfo.ParseThese] except that it's safe to call concurrently.
+func (s *Schema) ParseThese(args ...string) (*Command, error) {
+	return s.info.parsePrepared(args)
+}
+
+// ParseOrExit calls [Schema.ParseTheseOrExit] using os.Args as the command line
+// arguments.
+func (s *Schema) ParseOrExit() *Command {
+	return s.ParseTheseOrExit(os.Args[1:]...)
+}
+
+// ParseTheseOrExit parses args against this Schema. It works the same way as
+// [CommandInfo.ParseTheseOrExit] except that it's safe to call concurrently.
+func (s *Schema) ParseTheseOrExit(args ...string) *Command {
+	return exitOnError(s.ParseThese(args...))
+}
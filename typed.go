@@ -0,0 +1,234 @@
+package cli
+
+import "fmt"
+
+// Opt is a typed handle to an option whose parsed values are of type T. The handle's Info
+// is what gets added to a command (as in "cmd.Opt(port.Info)"), and the handle itself is
+// used to read the option's value from a parsed [Command]. Since the type is tied to the
+// option's parser, reading a value as the wrong type is a compile error rather than a
+// panic. See [TypedOpt].
+type Opt[T any] struct {
+	Info InputInfo
+}
+
+// Arg is a typed handle to a positional argument whose parsed values are of type T. It's
+// the positional argument counterpart of [Opt]. See [TypedArg].
+type Arg[T any] struct {
+	Info InputInfo
+}
+
+// TypedOpt returns a typed handle to a new option that uses the given function to parse
+// its values. The type of the handle is inferred from the function, so for example
+// "TypedOpt("port", strconv.Atoi)" returns an Opt[int]. Any of this library's value
+// parsers can be used through [TypedParser], as in
+// "TypedOpt("size", cli.TypedParser[uint64](cli.ParseByteSize))". The function must not
+// be nil (this will panic if it is). Use [TypedStringOpt] for an option whose raw values
+// are used as is.
+func TypedOpt[T any](id string, parse func(string) (T, error)) Opt[T] {
+	return Opt[T]{Info: NewOpt(id).WithParser(typedParser(parse))}
+}
+
+// TypedStringOpt returns a typed handle to a new option whose raw values are used as is.
+// See [NewOpt].
+func TypedStringOpt(id string) Opt[string] {
+	return Opt[string]{Info: NewOpt(id)}
+}
+
+// TypedBoolOpt returns a typed handle to a new boolean option. See [NewBoolOpt].
+func TypedBoolOpt(id string) Opt[bool] {
+	return Opt[bool]{Info: NewBoolOpt(id)}
+}
+
+// TypedArg returns a typed handle to a new positional argument that uses the given
+// function to parse its values. See [TypedOpt] for how the function is used.
+func TypedArg[T any](id string, parse func(string) (T, error)) Arg[T] {
+	return Arg[T]{Info: NewArg(id).WithParser(typedParser(parse))}
+}
+
+// TypedStringArg returns a typed handle to a new positional argument whose raw values are
+// used as is. See [NewArg].
+func TypedStringArg(id string) Arg[string] {
+	return Arg[string]{Info: NewArg(id)}
+}
+
+// TypedParser turns a [ValueParser] into a typed parse function for use with [TypedOpt]
+// and [TypedArg]. The type T must be the type of the values that vp returns (such as int
+// for [ParseInt] or time.Duration for [ParseExtendedDuration]). If vp ever returns a
+// value of a different type, the returned function reports it as a parsing error rather
+// than letting the value through.
+func TypedParser[T any](vp ValueParser) func(string) (T, error) {
+	return func(s string) (T, error) {
+		v, err := vp(s)
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+		t, ok := v.(T)
+		if !ok {
+			return t, fmt.Errorf("value parser returned a value of type %T, not %s",
+				v, fmt.Sprintf("%T", (*T)(nil))[1:])
+		}
+		return t, nil
+	}
+}
+
+// typedParser turns a typed parse function into a [ValueParser].
+func typedParser[T any](parse func(string) (T, error)) ValueParser {
+	if parse == nil {
+		panic(errNilTypedParser)
+	}
+	return func(s string) (any, error) {
+		return parse(s)
+	}
+}
+
+// Short is [InputInfo.Short] for a typed option.
+func (o Opt[T]) Short(c byte) Opt[T] {
+	o.Info = o.Info.Short(c)
+	return o
+}
+
+// Long is [InputInfo.Long] for a typed option.
+func (o Opt[T]) Long(name string) Opt[T] {
+	o.Info = o.Info.Long(name)
+	return o
+}
+
+// Help is [InputInfo.Help] for a typed option.
+func (o Opt[T]) Help(blurb string) Opt[T] {
+	o.Info = o.Info.Help(blurb)
+	return o
+}
+
+// Env is [InputInfo.Env] for a typed option.
+func (o Opt[T]) Env(e string) Opt[T] {
+	o.Info = o.Info.Env(e)
+	return o
+}
+
+// Default is [InputInfo.Default] for a typed option.
+func (o Opt[T]) Default(v string) Opt[T] {
+	o.Info = o.Info.Default(v)
+	return o
+}
+
+// Required is [InputInfo.Required] for a typed option.
+func (o Opt[T]) Required() Opt[T] {
+	o.Info = o.Info.Required()
+	return o
+}
+
+// Secret is [InputInfo.Secret] for a typed option.
+func (o Opt[T]) Secret() Opt[T] {
+	o.Info = o.Info.Secret()
+	return o
+}
+
+// With returns a copy of this handle with fn applied to its Info. This allows any of the
+// [InputInfo] builder methods to be used on a typed option, as in
+// "o.With(func(in cli.InputInfo) cli.InputInfo { return in.WithValueName("n") })".
+// Anything fn does that would change the type of the parsed values (such as setting a
+// different parser) is undone, since that would break the typing.
+func (o Opt[T]) With(fn func(InputInfo) InputInfo) Opt[T] {
+	o.Info = withSameType(o.Info, fn)
+	return o
+}
+
+// BindTo binds this option to dst using [BindTo]. Since dst must be a *T, the type of
+// the destination is checked at compile time.
+func (o Opt[T]) BindTo(dst *T) Opt[T] {
+	o.Info = o.Info.Bind(BindTo(dst))
+	return o
+}
+
+// Lookup is [Lookup] for this option.
+func (o Opt[T]) Lookup(c *Command) (T, bool) {
+	return Lookup[T](c, o.Info.ID)
+}
+
+// Get is [Get] for this option.
+func (o Opt[T]) Get(c *Command) T {
+	return Get[T](c, o.Info.ID)
+}
+
+// GetOr is [GetOr] for this option.
+func (o Opt[T]) GetOr(c *Command, fallback T) T {
+	return GetOr(c, o.Info.ID, fallback)
+}
+
+// GetAll is [GetAll] for this option.
+func (o Opt[T]) GetAll(c *Command) []T {
+	return GetAll[T](c, o.Info.ID)
+}
+
+// Help is [InputInfo.Help] for a typed positional argument.
+func (a Arg[T]) Help(blurb string) Arg[T] {
+	a.Info = a.Info.Help(blurb)
+	return a
+}
+
+// Env is [InputInfo.Env] for a typed positional argument.
+func (a Arg[T]) Env(e string) Arg[T] {
+	a.Info = a.Info.Env(e)
+	return a
+}
+
+// Default is [InputInfo.Default] for a typed positional argument.
+func (a Arg[T]) Default(v string) Arg[T] {
+	a.Info = a.Info.Default(v)
+	return a
+}
+
+// Required is [InputInfo.Required] for a typed positional argument.
+func (a Arg[T]) Required() Arg[T] {
+	a.Info = a.Info.Required()
+	return a
+}
+
+// Secret is [InputInfo.Secret] for a typed positional argument.
+func (a Arg[T]) Secret() Arg[T] {
+	a.Info = a.Info.Secret()
+	return a
+}
+
+// With is [Opt.With] for a typed positional argument.
+func (a Arg[T]) With(fn func(InputInfo) InputInfo) Arg[T] {
+	a.Info = withSameType(a.Info, fn)
+	return a
+}
+
+// withSameType returns the result of applying fn to in, but with the fields that
+// determine the type of the parsed values left as they are in in.
+func withSameType(in InputInfo, fn func(InputInfo) InputInfo) InputInfo {
+	out := fn(in)
+	out.ValueParser = in.ValueParser
+	out.IsBoolOpt = in.IsBoolOpt
+	out.IsMapOpt = in.IsMapOpt
+	return out
+}
+
+// BindTo is [Opt.BindTo] for a typed positional argument.
+func (a Arg[T]) BindTo(dst *T) Arg[T] {
+	a.Info = a.Info.Bind(BindTo(dst))
+	return a
+}
+
+// Lookup is [Lookup] for this positional argument.
+func (a Arg[T]) Lookup(c *Command) (T, bool) {
+	return Lookup[T](c, a.Info.ID)
+}
+
+// Get is [Get] for this positional argument.
+func (a Arg[T]) Get(c *Command) T {
+	return Get[T](c, a.Info.ID)
+}
+
+// GetOr is [GetOr] for this positional argument.
+func (a Arg[T]) GetOr(c *Command, fallback T) T {
+	return GetOr(c, a.Info.ID, fallback)
+}
+
+// GetAll is [GetAll] for this positional argument.
+func (a Arg[T]) GetAll(c *Command) []T {
+	return GetAll[T](c, a.Info.ID)
+}